@@ -0,0 +1,88 @@
+// Command collatz builds the tree of every start up to -max and writes it, or its statistics, without a
+// window
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/mykeelium/visual-playground/collatz"
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+func main() {
+	maxStart := flag.Int("max", 1000, "largest starting value")
+	q := flag.Int("q", 3, "odd values go to q*n+1; q must be odd and positive")
+	format := flag.String("format", "svg", "output: ascii, dot, json, svg, csv or stats")
+	out := flag.String("out", "", "output file, stdout when empty")
+	width := flag.Float64("width", 1024, "svg width")
+	height := flag.Float64("height", 1024, "svg height")
+	flag.Parse()
+
+	if err := run(*out, *format, *maxStart, *q, *width, *height); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var formats = []string{"ascii", "dot", "json", "svg", "csv", "stats"}
+
+// run writes to out, or stdout when it is empty, closing the file whether or not the write succeeds.
+// The format and rule are checked first, so a bad flag never leaves an empty file behind
+func run(out, format string, maxStart, q int, width, height float64) error {
+	if !slices.Contains(formats, format) {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	rule, err := collatz.QXPlusOne(q)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		return write(os.Stdout, format, maxStart, rule, width, height)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := write(f, format, maxStart, rule, width, height); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func write(w io.Writer, format string, maxStart int, rule collatz.Rule, width, height float64) error {
+	tree := collatz.BuildTreeWith(maxStart, rule, 0)
+	for _, c := range tree.Cycles {
+		log.Printf("cycle: %v", c)
+	}
+	if len(tree.Divergent) > 0 {
+		log.Printf("%d starts diverged, the first from %d", len(tree.Divergent), tree.Divergent[0].Start)
+	}
+
+	switch format {
+	case "ascii":
+		collatz.PrintOrganicTree(w, &tree)
+		return nil
+	case "dot":
+		return collatz.WriteDOT(w, &tree)
+	case "json":
+		return collatz.WriteJSON(w, &tree)
+	case "svg":
+		layout := collatz.LayoutTree(&tree, collatz.DefaultLayoutOptions())
+		return layout.WriteSVG(w, width, height, primitives.Color{Red: 0.95, Green: 0.55, Blue: 0.45}, primitives.Color{})
+	case "csv":
+		stats := collatz.Analyze(&tree, maxStart)
+		return stats.WriteCSV(w)
+	case "stats":
+		stats := collatz.Analyze(&tree, maxStart)
+		return stats.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
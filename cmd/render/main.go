@@ -0,0 +1,257 @@
+// Command render runs a simulation with a fixed timestep and writes the frames to numbered PNGs or an
+// animated GIF, without opening a window
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/mykeelium/visual-playground/engines"
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+	"github.com/mykeelium/visual-playground/renderers"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+type config struct {
+	mode     string
+	out      string
+	format   string
+	seed     int64
+	width    int
+	height   int
+	duration float64
+	fps      float64
+	count    int
+}
+
+// scene advances one fixed step and fills the mesh registry for the frame
+type scene interface {
+	Step(dt float64)
+	Root() renderers.RenderFn
+	Samples() []sources.Sample
+	// Channels are the samples of every engine channel, nil for scenes without an engine
+	Channels() map[string][]sources.Sample
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.mode, "mode", "circles", "scene to render: circles or scope")
+	flag.StringVar(&cfg.out, "out", "frames", "output directory for png, or output file for gif")
+	flag.StringVar(&cfg.format, "format", "png", "output format: png or gif")
+	flag.Int64Var(&cfg.seed, "seed", 42, "random seed")
+	flag.IntVar(&cfg.width, "width", 1024, "frame width in pixels")
+	flag.IntVar(&cfg.height, "height", 512, "frame height in pixels")
+	flag.Float64Var(&cfg.duration, "duration", 5, "length of the render in seconds")
+	flag.Float64Var(&cfg.fps, "fps", 30, "frames per second; the simulation steps at 1/fps")
+	flag.IntVar(&cfg.count, "count", 1000, "number of circles in the circles scene")
+	flag.Parse()
+
+	if err := render(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func render(cfg config) error {
+	if cfg.fps <= 0 || cfg.duration <= 0 || cfg.width <= 0 || cfg.height <= 0 {
+		return fmt.Errorf("fps, duration, width and height must be positive")
+	}
+
+	registry := meshes.NewMeshRegistry()
+
+	var sc scene
+	var err error
+	switch cfg.mode {
+	case "circles":
+		sc = newCircleScene(cfg, registry)
+	case "scope":
+		sc, err = newScopeScene(cfg, registry)
+	default:
+		return fmt.Errorf("unknown mode %q", cfg.mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	backend := renderers.NewRasterBackend(registry, cfg.width, cfg.height)
+	renderer := &renderers.GraphRenderer{
+		Root:    sc.Root(),
+		Backend: backend,
+	}
+
+	var anim *gif.GIF
+	var out *os.File
+	switch cfg.format {
+	case "png":
+		if err := os.MkdirAll(cfg.out, 0o755); err != nil {
+			return err
+		}
+	case "gif":
+		// create the file up front, so a bad path fails before any frames are rendered
+		if out, err = os.Create(cfg.out); err != nil {
+			return err
+		}
+		defer out.Close() // only matters on early returns; the Close below reports write errors
+		anim = &gif.GIF{}
+	default:
+		return fmt.Errorf("unknown format %q", cfg.format)
+	}
+
+	dt := 1 / cfg.fps
+	frames := int(math.Round(cfg.duration * cfg.fps))
+	// delays are in hundredths of a second, and most viewers replace anything under 2 with a slow default
+	delay := max(2, int(math.Round(100/cfg.fps)))
+
+	for i := range frames {
+		sc.Step(dt)
+
+		renderer.Render(&renderers.FrameContext{
+			Time:     float64(i+1) * dt,
+			Delta:    dt,
+			Size:     pixel.V(float64(cfg.width), float64(cfg.height)),
+			Samples:  sc.Samples(),
+			Channels: sc.Channels(),
+		})
+
+		if anim == nil {
+			path := filepath.Join(cfg.out, fmt.Sprintf("frame_%05d.png", i))
+			if err := backend.SavePNG(path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		img := backend.Image()
+		frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(frame, frame.Bounds(), img, image.Point{})
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	if anim == nil {
+		return nil
+	}
+
+	if err := gif.EncodeAll(out, anim); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// worldScene draws a primitives.World at the interpolated poses
+type worldScene struct {
+	world    *primitives.World
+	registry *meshes.MeshRegistry
+	meshIDs  []meshes.MeshID
+	// maxSpeed shades entities from blue to red by speed, as the playground window does; 0 keeps their colours
+	maxSpeed float64
+}
+
+func newWorldScene(world *primitives.World, registry *meshes.MeshRegistry) *worldScene {
+	s := &worldScene{
+		world:    world,
+		registry: registry,
+	}
+	for _, e := range world.Entities {
+		s.meshIDs = append(s.meshIDs, registry.Register(meshes.BuildEntityMesh(e)))
+	}
+	return s
+}
+
+// newCircleScene is the bouncing circles from the playground's main window
+func newCircleScene(cfg config, registry *meshes.MeshRegistry) *worldScene {
+	width, height := float64(cfg.width), float64(cfg.height)
+	rng := rand.New(rand.NewSource(cfg.seed))
+
+	bounds := primitives.WorldBounds{MinX: 0, MinY: 0, MaxX: width, MaxY: height}
+	world := primitives.NewWorld(bounds, primitives.Float2{X: 0, Y: -200})
+	world.Add(primitives.CreateChaoticCircles(rng, cfg.count, 5, width, height, 50, 200)...)
+
+	s := newWorldScene(world, registry)
+	s.maxSpeed = 800
+	return s
+}
+
+func (s *worldScene) Step(dt float64) {
+	s.world.Advance(dt)
+
+	for i, e := range s.world.Entities {
+		if s.maxSpeed > 0 {
+			e.UpdateColorBasedOnSpeed(s.maxSpeed)
+		}
+		mesh := meshes.BuildEntityMeshAt(e, s.world.InterpolatedPosition(i), s.world.InterpolatedAngle(i))
+		s.registry.Update(s.meshIDs[i], mesh)
+	}
+}
+
+func (s *worldScene) Root() renderers.RenderFn {
+	return func(ctx *renderers.RenderContext, fc *renderers.FrameContext) {
+		for _, id := range s.meshIDs {
+			ctx.Backend.DrawMesh(id, ctx.Transform)
+		}
+	}
+}
+
+func (s *worldScene) Samples() []sources.Sample             { return nil }
+func (s *worldScene) Channels() map[string][]sources.Sample { return nil }
+
+// scopeScene is the Lissajous oscilloscope demo
+type scopeScene struct {
+	params   *sources.ScopeParams
+	engine   *engines.Engine
+	registry *meshes.MeshRegistry
+	meshID   meshes.MeshID
+	width    float64
+	height   float64
+}
+
+func newScopeScene(cfg config, registry *meshes.MeshRegistry) (*scopeScene, error) {
+	rate := 12000.0
+	params := &sources.ScopeParams{
+		Gain:  0.9,
+		Decay: 0.25,
+		Fx:    3.0,
+		Fy:    2.0,
+	}
+
+	engine, err := engines.New(sources.NewLissajous(params, rate), engines.WithSampleRate(rate))
+	if err != nil {
+		return nil, err
+	}
+
+	return &scopeScene{
+		params:   params,
+		engine:   engine,
+		registry: registry,
+		meshID:   registry.Register(meshes.Mesh{Mode: meshes.DrawModeLine}),
+		width:    float64(cfg.width),
+		height:   float64(cfg.height),
+	}, nil
+}
+
+func (s *scopeScene) Step(dt float64) {
+	s.engine.Step(dt)
+	s.registry.Update(s.meshID, meshes.BuildOscilloscopeMesh(s.Samples(), s.params, s.width, s.height))
+}
+
+func (s *scopeScene) Root() renderers.RenderFn {
+	return renderers.Oscilloscope(s.meshID)
+}
+
+func (s *scopeScene) Samples() []sources.Sample {
+	return s.engine.Samples(engines.DefaultChannel)
+}
+
+func (s *scopeScene) Channels() map[string][]sources.Sample {
+	return s.engine.ChannelSamples()
+}
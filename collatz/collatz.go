@@ -3,6 +3,7 @@ package collatz
 
 import (
 	"fmt"
+	"io"
 )
 
 type Bud struct {
@@ -21,8 +22,15 @@ type Twig struct {
 type OrganicTree struct {
 	Root *Bud
 	Buds map[int]*Bud
+	// Cycles are loops other than the one through 1, each the root of its own tree
+	Cycles []Cycle
+	// Divergent are the starts whose trajectories were given up on; they have no buds
+	Divergent []Divergence
 }
 
+// DefaultMaxSteps is how far BuildTreeWith follows a trajectory before giving up on it, when not told
+const DefaultMaxSteps = 100000
+
 func NextInt(current int) int {
 	if current%2 == 0 {
 		return current / 2
@@ -31,11 +39,23 @@ func NextInt(current int) int {
 	}
 }
 
+// BuildTree grows a bud for every value visited from the starts up to maxNumber. For large trees use
+// BuildCompact
 func BuildTree(maxNumber int) OrganicTree {
+	return BuildTreeWith(maxNumber, NextInt, 0)
+}
+
+// BuildTreeWith is BuildTree for any rule. 1 is always the root and trajectories stop on reaching it.
+// Trajectories that loop back on themselves are recorded in Cycles, and those still going after maxSteps
+// new values, or that the rule can't map, in Divergent
+func BuildTreeWith(maxNumber int, rule Rule, maxSteps int) OrganicTree {
 	if maxNumber < 1 {
 		maxNumber = 1000
 	}
 	maxNumber++
+	if maxSteps < 1 {
+		maxSteps = DefaultMaxSteps
+	}
 
 	rootBud := Bud{
 		Value:    1,
@@ -47,55 +67,91 @@ func BuildTree(maxNumber int) OrganicTree {
 	}
 	rec.Buds[1] = &rootBud
 
+	// the new buds on the current trajectory, to spot it looping back on itself
+	var path []*Bud
+	onPath := map[int]int{}
+
 	for i := range maxNumber {
 		if i == 0 || i == 1 {
 			continue
 		}
+		if _, exists := rec.Buds[i]; exists {
+			// already grown as part of an earlier trajectory
+			continue
+		}
 
-		bud := Bud{
+		// follow the trajectory until it reaches the tree, adding a bud and twig for every new value
+		bud := &Bud{
 			Value:    i,
 			Children: []*Twig{},
 		}
-		rec.Buds[i] = &bud
-		innerGrow := i
+		rec.Buds[i] = bud
+		path = append(path[:0], bud)
+		clear(onPath)
+		onPath[i] = 0
 		for {
-			nextInt := NextInt(innerGrow)
-			parent, exists := rec.Buds[nextInt]
-			if exists {
-				xAngle, yAngle := getAngle(nextInt)
-				newTwig := Twig{
-					Child:  &bud,
-					Parent: parent,
-					XAngle: xAngle,
-					YAngle: yAngle,
+			nextInt := rule(bud.Value)
+			if nextInt < 1 || len(path) > maxSteps {
+				// drop the partial trajectory so later starts don't attach to it
+				rec.Divergent = append(rec.Divergent, Divergence{Start: i, Last: bud.Value, Steps: len(path) - 1})
+				for _, b := range path {
+					delete(rec.Buds, b.Value)
+				}
+				break
+			}
+			if at, looped := onPath[nextInt]; looped {
+				values := make([]int, 0, len(path)-at)
+				for _, b := range path[at:] {
+					values = append(values, b.Value)
 				}
-				bud.Parent = &newTwig
-				parent.Children = append(parent.Children, &newTwig)
+				rec.Cycles = append(rec.Cycles, newCycle(values, bud, nextInt))
 				break
-			} else {
-				innerGrow = nextInt
-				newBud := Bud{
-					Value:    innerGrow,
+			}
+
+			parent, exists := rec.Buds[nextInt]
+			if !exists {
+				parent = &Bud{
+					Value:    nextInt,
 					Children: []*Twig{},
 				}
-				rec.Buds[innerGrow] = &newBud
+				rec.Buds[nextInt] = parent
+				onPath[nextInt] = len(path)
+				path = append(path, parent)
 			}
-		}
 
+			xAngle, yAngle := getAngle(bud.Value)
+			newTwig := Twig{
+				Child:  bud,
+				Parent: parent,
+				XAngle: xAngle,
+				YAngle: yAngle,
+			}
+			bud.Parent = &newTwig
+			parent.Children = append(parent.Children, &newTwig)
+
+			if exists {
+				break
+			}
+			bud = parent
+		}
 	}
 
 	return rec
 }
 
-func PrintOrganicTree(t *OrganicTree) {
-	if t.Root == nil {
-		fmt.Println("(empty tree)")
+// PrintOrganicTree writes the tree to w as ASCII art, 1 first and then the tree of each cycle
+func PrintOrganicTree(w io.Writer, t *OrganicTree) {
+	roots := t.roots()
+	if len(roots) == 0 {
+		fmt.Fprintln(w, "(empty tree)")
 		return
 	}
-	printBud(t.Root, "", true)
+	for _, root := range roots {
+		printBud(w, root, "", true)
+	}
 }
 
-func printBud(b *Bud, prefix string, isLast bool) {
+func printBud(w io.Writer, b *Bud, prefix string, isLast bool) {
 	connector := "├─"
 	nextPrefix := prefix + "│  "
 	if isLast {
@@ -105,9 +161,9 @@ func printBud(b *Bud, prefix string, isLast bool) {
 
 	// Print this Bud
 	if prefix == "" {
-		fmt.Printf("Bud(%d)\n", b.Value)
+		fmt.Fprintf(w, "Bud(%d)\n", b.Value)
 	} else {
-		fmt.Printf("%s%s Bud(%d)\n", prefix, connector, b.Value)
+		fmt.Fprintf(w, "%s%s Bud(%d)\n", prefix, connector, b.Value)
 	}
 
 	// Print its Twigs → Children
@@ -121,11 +177,11 @@ func printBud(b *Bud, prefix string, isLast bool) {
 			twigNextPrefix = nextPrefix + "   "
 		}
 
-		fmt.Printf("%s%s Twig(x=%d, y=%d) → Bud(%d)\n",
+		fmt.Fprintf(w, "%s%s Twig(x=%d, y=%d) → Bud(%d)\n",
 			nextPrefix, twigConnector, twig.XAngle, twig.YAngle, twig.Child.Value)
 
 		// Recursively print the child Bud
-		printBud(twig.Child, twigNextPrefix, lastTwig)
+		printBud(w, twig.Child, twigNextPrefix, lastTwig)
 	}
 }
 
@@ -0,0 +1,264 @@
+package collatz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// ErrOverflow is returned when a trajectory climbs past what a uint64 can hold
+var ErrOverflow = errors.New("collatz: trajectory overflows uint64")
+
+// ErrPruned is returned by Organic for pruned trees, whose edges skip the values above MaxStart
+var ErrPruned = errors.New("collatz: pruned trees have no organic form")
+
+// BuildOptions controls BuildCompact
+type BuildOptions struct {
+	// MaxStart is the largest starting value; every start from 1 to MaxStart is in the tree
+	MaxStart uint64
+	// Prune keeps only values up to MaxStart, linking each to the next one on its trajectory with the
+	// number of steps skipped. Otherwise every value visited is a node
+	Prune bool
+	// Workers computing trajectories, GOMAXPROCS when 0. The tree is the same for any count
+	Workers int
+}
+
+// CompactTree is the tree as flat slices indexed by node, so millions of values fit without a pointer
+// and map entry each. Values up to MaxStart are at index value-1, so the root is node 0; values above
+// are appended in ascending order
+type CompactTree struct {
+	MaxStart uint64
+	Pruned   bool
+	Values   []uint64
+	// Parents is the index of the next node on each trajectory, -1 for the root
+	Parents []int32
+	// Steps is how many Collatz steps each node is from its parent, more than 1 only when pruned
+	Steps []uint32
+
+	childStart []int32
+	children   []int32
+}
+
+// BuildCompact builds the tree of every start up to opts.MaxStart. Trajectories are followed in
+// parallel and merged in value order, so the result doesn't depend on the number of workers
+func BuildCompact(opts BuildOptions) (*CompactTree, error) {
+	n := opts.MaxStart
+	if n < 1 {
+		n = 1
+	}
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("collatz: %d starts is more than a compact tree can index", n)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	t := &CompactTree{MaxStart: n, Pruned: opts.Prune}
+
+	// values above n, found per worker and merged
+	var above []uint64
+	if !opts.Prune {
+		found := make([][]uint64, workers)
+		err := forChunks(n, workers, func(w int, lo, hi uint64) error {
+			var err error
+			found[w], err = aboveValues(lo, hi, n)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range found {
+			above = append(above, f...)
+		}
+		slices.Sort(above)
+		above = slices.Compact(above)
+	}
+
+	total := n + uint64(len(above))
+	if total > math.MaxInt32 {
+		return nil, fmt.Errorf("collatz: %d nodes is more than a compact tree can index", total)
+	}
+	t.Values = make([]uint64, total)
+	t.Parents = make([]int32, total)
+	t.Steps = make([]uint32, total)
+	for i := range n {
+		t.Values[i] = i + 1
+	}
+	copy(t.Values[n:], above)
+
+	err := forChunks(total, workers, func(w int, lo, hi uint64) error {
+		for i := lo - 1; i < hi; i++ {
+			v := t.Values[i]
+			if v == 1 {
+				t.Parents[i] = -1
+				continue
+			}
+			next, steps := v, uint32(0)
+			for {
+				var err error
+				if next, err = step(next); err != nil {
+					return err
+				}
+				steps++
+				if !opts.Prune || next <= n {
+					break
+				}
+			}
+			t.Parents[i] = int32(t.index(next))
+			t.Steps[i] = steps
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.link()
+	return t, nil
+}
+
+// step is NextInt on uint64, reporting overflow
+func step(v uint64) (uint64, error) {
+	if v%2 == 0 {
+		return v / 2, nil
+	}
+	if v > (math.MaxUint64-1)/3 {
+		return 0, fmt.Errorf("%w: 3*%d+1", ErrOverflow, v)
+	}
+	return 3*v + 1, nil
+}
+
+// aboveValues collects the values above n visited from starts lo to hi, sorted. A trajectory is only
+// followed while it is above n, since the value it falls back to is a start whose worker collects the
+// rest. It also stops on halving into a value whose other predecessor, (v-1)/3, is a start, which then
+// collects it, so few values are found twice
+func aboveValues(lo, hi, n uint64) ([]uint64, error) {
+	var out []uint64
+	for s := lo; s <= hi; s++ {
+		if s%2 == 0 || s == 1 {
+			// halving only falls, and 1 is the root
+			continue
+		}
+		v, err := step(s)
+		if err != nil {
+			return nil, err
+		}
+		for v > n {
+			out = append(out, v)
+			if v%2 != 0 {
+				if v, err = step(v); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v /= 2
+			if v%6 == 4 && v > 4 && (v-1)/3 <= n {
+				break
+			}
+		}
+	}
+
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// forChunks splits 1..count into contiguous chunks, one per worker, and returns the first error
+func forChunks(count uint64, workers int, fn func(w int, lo, hi uint64) error) error {
+	size := (count + uint64(workers) - 1) / uint64(workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		lo := uint64(w)*size + 1
+		hi := min(lo+size-1, count)
+		if lo > hi {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[w] = fn(w, lo, hi)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// link builds the child lists from the parents, children in ascending index order
+func (t *CompactTree) link() {
+	t.childStart = make([]int32, len(t.Values)+1)
+	for _, p := range t.Parents {
+		if p >= 0 {
+			t.childStart[p+1]++
+		}
+	}
+	for i := 1; i < len(t.childStart); i++ {
+		t.childStart[i] += t.childStart[i-1]
+	}
+
+	t.children = make([]int32, t.childStart[len(t.Values)])
+	next := slices.Clone(t.childStart[:len(t.Values)])
+	for i, p := range t.Parents {
+		if p >= 0 {
+			t.children[next[p]] = int32(i)
+			next[p]++
+		}
+	}
+}
+
+func (t *CompactTree) Len() int {
+	return len(t.Values)
+}
+
+// Index finds the node holding value
+func (t *CompactTree) Index(value uint64) (int, bool) {
+	if value == 0 {
+		return 0, false
+	}
+	if value <= t.MaxStart {
+		return int(value - 1), true
+	}
+	above := t.Values[t.MaxStart:]
+	i, ok := slices.BinarySearch(above, value)
+	return int(t.MaxStart) + i, ok
+}
+
+// index is Index for values known to be in the tree
+func (t *CompactTree) index(value uint64) int {
+	i, _ := t.Index(value)
+	return i
+}
+
+// Children are the nodes whose next value is node i
+func (t *CompactTree) Children(i int) []int32 {
+	return t.children[t.childStart[i]:t.childStart[i+1]]
+}
+
+// Organic converts the tree to an OrganicTree, for laying out, printing and Analyze. Only sensible for small
+// trees, and refused for pruned ones: an OrganicTree has one step per twig and every value on the way
+func (t *CompactTree) Organic() (OrganicTree, error) {
+	if t.Pruned {
+		return OrganicTree{}, ErrPruned
+	}
+
+	rec := OrganicTree{Buds: make(map[int]*Bud, len(t.Values))}
+	for _, v := range t.Values {
+		rec.Buds[int(v)] = &Bud{Value: int(v), Children: []*Twig{}}
+	}
+	rec.Root = rec.Buds[1]
+
+	for i, p := range t.Parents {
+		if p < 0 {
+			continue
+		}
+		child, parent := rec.Buds[int(t.Values[i])], rec.Buds[int(t.Values[p])]
+		xAngle, yAngle := getAngle(child.Value)
+		twig := &Twig{Parent: parent, Child: child, XAngle: xAngle, YAngle: yAngle}
+		child.Parent = twig
+		parent.Children = append(parent.Children, twig)
+	}
+	return rec, nil
+}
@@ -0,0 +1,106 @@
+package collatz
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestOrganicMatchesBuildTree(t *testing.T) {
+	compact, err := BuildCompact(BuildOptions{MaxStart: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	organic, err := compact.Organic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := BuildTree(200)
+	want := Analyze(&tree, 200)
+	got := Analyze(&organic, 200)
+	if got.LongestChain != want.LongestChain {
+		t.Errorf("longest chain %+v, want %+v", got.LongestChain, want.LongestChain)
+	}
+	if len(got.Values) != len(want.Values) {
+		t.Errorf("%d values, want %d", len(got.Values), len(want.Values))
+	}
+}
+
+func TestOrganicRefusesPrunedTrees(t *testing.T) {
+	compact, err := BuildCompact(BuildOptions{MaxStart: 200, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compact.Organic(); !errors.Is(err, ErrPruned) {
+		t.Errorf("Organic on a pruned tree returned %v, want ErrPruned", err)
+	}
+}
+
+func TestBuildCompactSameForAnyWorkerCount(t *testing.T) {
+	for _, prune := range []bool{false, true} {
+		one, err := BuildCompact(BuildOptions{MaxStart: 5000, Prune: prune, Workers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, workers := range []int{2, 3, 8} {
+			many, err := BuildCompact(BuildOptions{MaxStart: 5000, Prune: prune, Workers: workers})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(one.Values, many.Values) || !slices.Equal(one.Parents, many.Parents) ||
+				!slices.Equal(one.Steps, many.Steps) {
+				t.Errorf("pruned %v: %d workers built a different tree than 1", prune, workers)
+			}
+		}
+	}
+}
+
+func TestStepReportsOverflow(t *testing.T) {
+	limit := uint64(math.MaxUint64-1) / 3 // the largest odd value whose 3x+1 fits
+	if limit%2 == 0 {
+		limit--
+	}
+	if v, err := step(limit); err != nil || v != 3*limit+1 {
+		t.Errorf("step(%d) = %d, %v, want %d", limit, v, err, 3*limit+1)
+	}
+	if _, err := step(limit + 2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("step(%d) returned %v, want ErrOverflow", limit+2, err)
+	}
+	if v, err := step(math.MaxUint64 - 1); err != nil || v != math.MaxUint64/2 {
+		t.Errorf("halving the largest even value gave %d, %v", v, err)
+	}
+}
+
+// TestPrunedSteps follows each pruned edge step by step: it must land on the parent after Steps values,
+// passing only values above MaxStart on the way
+func TestPrunedSteps(t *testing.T) {
+	const n = 1000
+	tree, err := BuildCompact(BuildOptions{MaxStart: n, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Len() != n {
+		t.Fatalf("pruned tree has %d nodes, want %d", tree.Len(), n)
+	}
+
+	for i, p := range tree.Parents {
+		if p < 0 {
+			continue
+		}
+		v := tree.Values[i]
+		for range tree.Steps[i] - 1 {
+			if v, _ = step(v); v <= n {
+				t.Fatalf("%d: skipped over %d, which is in the tree", tree.Values[i], v)
+			}
+		}
+		if v, _ = step(v); v != tree.Values[p] {
+			t.Errorf("%d: %d steps reach %d, want its parent %d", tree.Values[i], tree.Steps[i], v, tree.Values[p])
+		}
+	}
+	// 703 has the longest climb of the starts up to 1000, first coming back down at 628 after 132 steps
+	if i := tree.index(703); tree.Values[tree.Parents[i]] != 628 || tree.Steps[i] != 132 {
+		t.Errorf("703 links to %d after %d steps, want 628 after 132", tree.Values[tree.Parents[i]], tree.Steps[i])
+	}
+}
@@ -0,0 +1,183 @@
+package collatz
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+// roots are the buds the tree grows from: 1, then the cycles
+func (t *OrganicTree) roots() []*Bud {
+	var out []*Bud
+	if t.Root != nil {
+		out = append(out, t.Root)
+	}
+	for _, c := range t.Cycles {
+		out = append(out, c.Root)
+	}
+	return out
+}
+
+// walk visits every bud reachable from the roots, parents before children, with its depth
+func (t *OrganicTree) walk(fn func(b *Bud, depth int)) {
+	type visit struct {
+		bud   *Bud
+		depth int
+	}
+	for _, root := range t.roots() {
+		stack := []visit{{root, 0}}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			fn(v.bud, v.depth)
+			for i := len(v.bud.Children) - 1; i >= 0; i-- {
+				stack = append(stack, visit{v.bud.Children[i].Child, v.depth + 1})
+			}
+		}
+	}
+}
+
+// evenTwig reports whether the twig turns the way an even child does
+func evenTwig(twig *Twig) bool {
+	even, _ := getAngle(0)
+	return twig.XAngle == even
+}
+
+// WriteDOT writes the tree as a Graphviz digraph with edges from parent to child. Even twigs are solid and
+// odd ones dashed, and each cycle's closing edge is drawn in bold back to where it loops
+func WriteDOT(w io.Writer, t *OrganicTree) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph collatz {")
+	fmt.Fprintln(bw, "\trankdir=BT;")
+	fmt.Fprintln(bw, "\tnode [shape=circle, fontsize=10];")
+	fmt.Fprintln(bw, "\t1 [style=filled, fillcolor=\"#f2c14e\"];")
+
+	t.walk(func(b *Bud, depth int) {
+		for _, twig := range b.Children {
+			style := "color=\"#d1495b\", style=dashed"
+			if evenTwig(twig) {
+				style = "color=\"#2e86ab\", style=solid"
+			}
+			fmt.Fprintf(bw, "\t%d -> %d [%s, comment=\"x=%d y=%d\"];\n", b.Value, twig.Child.Value, style, twig.XAngle, twig.YAngle)
+		}
+	})
+	for _, c := range t.Cycles {
+		fmt.Fprintf(bw, "\t%d [style=filled, fillcolor=\"#9bc53d\"];\n", c.Root.Value)
+		fmt.Fprintf(bw, "\t%d -> %d [style=bold, constraint=false];\n", c.Root.Value, c.Entry)
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// JSONNode is a value in the tree, for WriteJSON
+type JSONNode struct {
+	ID    int    `json:"id"`
+	Depth int    `json:"depth"`
+	Root  bool   `json:"root,omitempty"`
+	Kind  string `json:"kind"`
+}
+
+// JSONEdge links a parent to a child, for WriteJSON. Kind is even or odd, or cycle for the edge closing a
+// cycle, which runs from its root back to where the loop was entered
+type JSONEdge struct {
+	Source int    `json:"source"`
+	Target int    `json:"target"`
+	Kind   string `json:"kind"`
+	XAngle int    `json:"x_angle"`
+	YAngle int    `json:"y_angle"`
+}
+
+// JSONGraph is the node and edge lists most web graph tools read
+type JSONGraph struct {
+	Nodes     []JSONNode   `json:"nodes"`
+	Edges     []JSONEdge   `json:"edges"`
+	Cycles    [][]int      `json:"cycles,omitempty"`
+	Divergent []Divergence `json:"divergent,omitempty"`
+}
+
+// Graph lists the tree's nodes, parents first, and its edges from parent to child
+func Graph(t *OrganicTree) JSONGraph {
+	var g JSONGraph
+	t.walk(func(b *Bud, depth int) {
+		g.Nodes = append(g.Nodes, JSONNode{ID: b.Value, Depth: depth, Root: b.Parent == nil, Kind: parity(b.Value)})
+		for _, twig := range b.Children {
+			kind := "odd"
+			if evenTwig(twig) {
+				kind = "even"
+			}
+			g.Edges = append(g.Edges, JSONEdge{
+				Source: b.Value,
+				Target: twig.Child.Value,
+				Kind:   kind,
+				XAngle: twig.XAngle,
+				YAngle: twig.YAngle,
+			})
+		}
+	})
+	for _, c := range t.Cycles {
+		g.Edges = append(g.Edges, JSONEdge{Source: c.Root.Value, Target: c.Entry, Kind: "cycle"})
+		g.Cycles = append(g.Cycles, c.Values)
+	}
+	g.Divergent = t.Divergent
+	return g
+}
+
+func parity(v int) string {
+	if v%2 == 0 {
+		return "even"
+	}
+	return "odd"
+}
+
+// WriteJSON writes the tree's Graph
+func WriteJSON(w io.Writer, t *OrganicTree) error {
+	return json.NewEncoder(w).Encode(Graph(t))
+}
+
+// Fit maps the layout into a width by height frame, centred and keeping its aspect, inside margin
+func (l *Layout) Fit(width, height, margin float64) primitives.Matrix {
+	size := l.Bounds.Max.Sub(l.Bounds.Min)
+	scale := math.Min((width-2*margin)/math.Max(size.X, 1), (height-2*margin)/math.Max(size.Y, 1))
+	centre := l.Bounds.Min.Add(size.Scale(0.5))
+	return primitives.IM.
+		Moved(centre.Scale(-1)).
+		ScaledXY(primitives.ZeroFloat2, primitives.Float2{X: scale, Y: scale}).
+		Moved(primitives.Float2{X: width / 2, Y: height / 2})
+}
+
+// WriteSVG draws the layout fitted to a width by height image, one path per depth so thickness falls off
+// as in Meshes
+func (l *Layout) WriteSVG(w io.Writer, width, height float64, color, background primitives.Color) error {
+	m := l.Fit(width, height, 20)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\" viewBox=\"0 0 %g %g\">\n", width, height, width, height)
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", hex(background))
+	fmt.Fprintf(bw, "<g fill=\"none\" stroke=\"%s\" stroke-linecap=\"round\">\n", hex(color))
+
+	for _, mesh := range l.Meshes(color) {
+		if len(mesh.Vertices) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "<path stroke-width=\"%.2f\" d=\"", mesh.Thickness)
+		for i := 0; i+1 < len(mesh.Vertices); i += 2 {
+			// svg's y axis points down
+			a, b := m.Project(mesh.Vertices[i]), m.Project(mesh.Vertices[i+1])
+			fmt.Fprintf(bw, "M%.1f %.1fL%.1f %.1f", a.X, height-a.Y, b.X, height-b.Y)
+		}
+		fmt.Fprintln(bw, "\"/>")
+	}
+
+	fmt.Fprintln(bw, "</g>")
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func hex(c primitives.Color) string {
+	channel := func(v float64) int { return int(math.Round(255 * math.Max(0, math.Min(1, v)))) }
+	return fmt.Sprintf("#%02x%02x%02x", channel(c.Red), channel(c.Green), channel(c.Blue))
+}
@@ -0,0 +1,58 @@
+package collatz
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fivePlusOne falls into two cycles below 20, neither through its smallest value first
+func fivePlusOne(t *testing.T) (OrganicTree, Rule) {
+	t.Helper()
+	rule, err := QXPlusOne(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BuildTreeWith(20, rule, 0), rule
+}
+
+func TestCycleClosesAtItsEntry(t *testing.T) {
+	tree, rule := fivePlusOne(t)
+	if len(tree.Cycles) != 2 {
+		t.Fatalf("found %d cycles, want 2", len(tree.Cycles))
+	}
+
+	var dot bytes.Buffer
+	if err := WriteDOT(&dot, &tree); err != nil {
+		t.Fatal(err)
+	}
+	graph := Graph(&tree)
+
+	for _, c := range tree.Cycles {
+		if c.Entry != rule(c.Root.Value) || !slices.Contains(c.Values, c.Entry) {
+			t.Errorf("cycle %v: entry %d, want %d on the loop", c, c.Entry, rule(c.Root.Value))
+		}
+		edge := fmt.Sprintf("\t%d -> %d [style=bold", c.Root.Value, c.Entry)
+		if !strings.Contains(dot.String(), edge) {
+			t.Errorf("cycle %v: dot has no closing edge %q", c, edge)
+		}
+		closing := JSONEdge{Source: c.Root.Value, Target: c.Entry, Kind: "cycle"}
+		if !slices.Contains(graph.Edges, closing) {
+			t.Errorf("cycle %v: json has no closing edge %+v", c, closing)
+		}
+	}
+}
+
+func TestCycleTreesArePrinted(t *testing.T) {
+	tree, _ := fivePlusOne(t)
+
+	var out bytes.Buffer
+	PrintOrganicTree(&out, &tree)
+	for _, c := range tree.Cycles {
+		if !strings.Contains(out.String(), fmt.Sprintf("\nBud(%d)\n", c.Root.Value)) {
+			t.Errorf("cycle %v: its tree isn't printed", c)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package collatz
+
+import (
+	"math"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+// LayoutOptions controls how the tree is grown into the plane. Angles are in degrees, counter clockwise
+type LayoutOptions struct {
+	// EvenAngle and OddAngle are the turn taken by a twig whose child is even or odd
+	EvenAngle float64
+	OddAngle  float64
+	// Heading is the direction of the twigs leaving the root
+	Heading float64
+	Origin  primitives.Float2
+
+	SegmentLength float64
+	// LengthFalloff scales each generation's segments from the last; 0 keeps them all SegmentLength
+	LengthFalloff float64
+
+	Thickness float64
+	// ThicknessFalloff scales each generation's thickness from the last, down to MinThickness; 0 keeps
+	// them all Thickness
+	ThicknessFalloff float64
+	MinThickness     float64
+}
+
+// DefaultLayoutOptions grows the classic coral upwards, turning by the twig angles BuildTree records
+func DefaultLayoutOptions() LayoutOptions {
+	even, _ := getAngle(0)
+	odd, _ := getAngle(1)
+	return LayoutOptions{
+		EvenAngle:        float64(even),
+		OddAngle:         float64(odd),
+		Heading:          90,
+		SegmentLength:    10,
+		Thickness:        6,
+		ThicknessFalloff: 0.95,
+		MinThickness:     1,
+	}
+}
+
+// LayoutSegment is one twig placed in the plane; Depth is the child's distance from the root in twigs
+type LayoutSegment struct {
+	From  primitives.Float2
+	To    primitives.Float2
+	Depth int
+}
+
+// Branch is a polyline following a run of first children, starting Depth twigs from the root
+type Branch struct {
+	Points []primitives.Float2
+	Depth  int
+}
+
+// Layout is a tree placed in the plane
+type Layout struct {
+	Options   LayoutOptions
+	Positions map[int]primitives.Float2
+	Segments  []LayoutSegment
+	Branches  []Branch
+	Bounds    primitives.AABB
+	MaxDepth  int
+}
+
+// LayoutTree walks the tree from its root. Each twig turns from its parent's heading by the even or odd
+// angle of its child and steps forward by the segment length for its depth. The tree of each cycle is
+// grown the same way and stood to the right of the ones before it
+func LayoutTree(t *OrganicTree, opts LayoutOptions) Layout {
+	l := Layout{
+		Options:   opts,
+		Positions: map[int]primitives.Float2{},
+		Bounds:    primitives.AABB{Min: opts.Origin, Max: opts.Origin},
+	}
+	for i, root := range t.roots() {
+		tree := layoutRoot(root, opts)
+		if i > 0 {
+			gap := 4 * opts.SegmentLength
+			tree.move(primitives.Float2{X: l.Bounds.Max.X + gap - tree.Bounds.Min.X})
+		}
+		l.merge(&tree)
+	}
+	return l
+}
+
+// layoutRoot lays out the tree below root, with root at the origin
+func layoutRoot(root *Bud, opts LayoutOptions) Layout {
+	l := Layout{
+		Options:   opts,
+		Positions: map[int]primitives.Float2{},
+		Bounds:    primitives.AABB{Min: opts.Origin, Max: opts.Origin},
+	}
+	l.Positions[root.Value] = opts.Origin
+
+	type visit struct {
+		bud     *Bud
+		heading float64
+		depth   int
+		branch  int // index into Branches the bud ends, -1 to start a new one
+	}
+
+	// depth first with an explicit stack, since long trajectories make deep trees
+	stack := []visit{{bud: root, heading: opts.Heading, branch: -1}}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		from := l.Positions[v.bud.Value]
+
+		// pushed in reverse so the first child is laid out first and continues the branch
+		for i := len(v.bud.Children) - 1; i >= 0; i-- {
+			child := v.bud.Children[i].Child
+			heading := v.heading + opts.turn(child.Value)
+			rad := heading * math.Pi / 180
+			to := from.Add(primitives.Float2{X: math.Cos(rad), Y: math.Sin(rad)}.Scale(opts.length(v.depth + 1)))
+
+			l.Positions[child.Value] = to
+			l.Segments = append(l.Segments, LayoutSegment{From: from, To: to, Depth: v.depth + 1})
+			l.grow(to)
+			l.MaxDepth = max(l.MaxDepth, v.depth+1)
+
+			branch := -1
+			if i == 0 {
+				branch = v.branch
+			}
+			if branch < 0 {
+				l.Branches = append(l.Branches, Branch{Points: []primitives.Float2{from}, Depth: v.depth})
+				branch = len(l.Branches) - 1
+			}
+			l.Branches[branch].Points = append(l.Branches[branch].Points, to)
+
+			stack = append(stack, visit{bud: child, heading: heading, depth: v.depth + 1, branch: branch})
+		}
+	}
+	return l
+}
+
+// move shifts everything laid out by d
+func (l *Layout) move(d primitives.Float2) {
+	for v, p := range l.Positions {
+		l.Positions[v] = p.Add(d)
+	}
+	for i := range l.Segments {
+		l.Segments[i].From = l.Segments[i].From.Add(d)
+		l.Segments[i].To = l.Segments[i].To.Add(d)
+	}
+	for _, b := range l.Branches {
+		for i := range b.Points {
+			b.Points[i] = b.Points[i].Add(d)
+		}
+	}
+	l.Bounds.Min = l.Bounds.Min.Add(d)
+	l.Bounds.Max = l.Bounds.Max.Add(d)
+}
+
+// merge adds another layout's buds and segments to l
+func (l *Layout) merge(o *Layout) {
+	for v, p := range o.Positions {
+		l.Positions[v] = p
+	}
+	l.Segments = append(l.Segments, o.Segments...)
+	l.Branches = append(l.Branches, o.Branches...)
+	l.grow(o.Bounds.Min)
+	l.grow(o.Bounds.Max)
+	l.MaxDepth = max(l.MaxDepth, o.MaxDepth)
+}
+
+func (o LayoutOptions) turn(value int) float64 {
+	if value%2 == 0 {
+		return o.EvenAngle
+	}
+	return o.OddAngle
+}
+
+func (o LayoutOptions) length(depth int) float64 {
+	if o.LengthFalloff == 0 {
+		return o.SegmentLength
+	}
+	return o.SegmentLength * math.Pow(o.LengthFalloff, float64(depth-1))
+}
+
+// Thickness is how thick segments at depth are drawn
+func (l *Layout) Thickness(depth int) float64 {
+	if l.Options.ThicknessFalloff == 0 {
+		return math.Max(l.Options.Thickness, l.Options.MinThickness)
+	}
+	t := l.Options.Thickness * math.Pow(l.Options.ThicknessFalloff, float64(depth-1))
+	return math.Max(t, l.Options.MinThickness)
+}
+
+func (l *Layout) grow(p primitives.Float2) {
+	l.Bounds.Min = primitives.Float2{X: math.Min(l.Bounds.Min.X, p.X), Y: math.Min(l.Bounds.Min.Y, p.Y)}
+	l.Bounds.Max = primitives.Float2{X: math.Max(l.Bounds.Max.X, p.X), Y: math.Max(l.Bounds.Max.Y, p.Y)}
+}
+
+// Meshes builds one segments mesh per depth, thickness falling off away from the root. They are ordered
+// from the root outwards
+func (l *Layout) Meshes(color primitives.Color) []meshes.Mesh {
+	out := make([]meshes.Mesh, l.MaxDepth)
+	for i := range out {
+		out[i] = meshes.Mesh{
+			Mode:      meshes.DrawModeSegments,
+			Color:     &color,
+			Thickness: l.Thickness(i + 1),
+		}
+	}
+	for _, s := range l.Segments {
+		m := &out[s.Depth-1]
+		m.Vertices = append(m.Vertices, s.From, s.To)
+	}
+	return out
+}
+
+// BranchMeshes builds a line mesh per branch, each as thick as its first segment
+func (l *Layout) BranchMeshes(color primitives.Color) []meshes.Mesh {
+	out := make([]meshes.Mesh, len(l.Branches))
+	for i, b := range l.Branches {
+		out[i] = meshes.Mesh{
+			Vertices:  b.Points,
+			Mode:      meshes.DrawModeLine,
+			Color:     &color,
+			Thickness: l.Thickness(b.Depth + 1),
+		}
+	}
+	return out
+}
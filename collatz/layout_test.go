@@ -0,0 +1,131 @@
+package collatz
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+func nearPoint(a, b primitives.Float2) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+// squareLayout turns left on odd values and goes straight on even ones, one unit per twig
+func squareLayout() LayoutOptions {
+	return LayoutOptions{OddAngle: 90, SegmentLength: 1, Thickness: 4, ThicknessFalloff: 0.5, MinThickness: 1}
+}
+
+func TestLayoutPlacesTrajectory(t *testing.T) {
+	// up to 4 the tree is the single trajectory 3 → 10 → 5 → 16 → 8 → 4 → 2 → 1
+	tree := BuildTree(4)
+	l := LayoutTree(&tree, squareLayout())
+
+	want := map[int]primitives.Float2{
+		1: {}, 2: {X: 1}, 4: {X: 2}, 8: {X: 3}, 16: {X: 4},
+		5: {X: 4, Y: 1}, 10: {X: 4, Y: 2}, 3: {X: 3, Y: 2},
+	}
+	if len(l.Positions) != len(want) {
+		t.Errorf("laid out %d values, want %d", len(l.Positions), len(want))
+	}
+	for v, p := range want {
+		if got, ok := l.Positions[v]; !ok || !nearPoint(got, p) {
+			t.Errorf("%d at %v, want %v", v, got, p)
+		}
+	}
+
+	if !nearPoint(l.Bounds.Min, primitives.Float2{}) || !nearPoint(l.Bounds.Max, primitives.Float2{X: 4, Y: 2}) {
+		t.Errorf("bounds %v, want (0, 0) to (4, 2)", l.Bounds)
+	}
+	if l.MaxDepth != 7 || len(l.Segments) != 7 {
+		t.Errorf("max depth %d with %d segments, want 7 and 7", l.MaxDepth, len(l.Segments))
+	}
+	// every twig is a first child, so the whole trajectory is one branch
+	if len(l.Branches) != 1 || len(l.Branches[0].Points) != 8 {
+		t.Errorf("got %d branches, want one of 8 points", len(l.Branches))
+	}
+}
+
+func TestLayoutLengthFalloff(t *testing.T) {
+	tree := BuildTree(4)
+	opts := squareLayout()
+	opts.LengthFalloff = 0.5
+	l := LayoutTree(&tree, opts)
+
+	for _, s := range l.Segments {
+		want := math.Pow(0.5, float64(s.Depth-1))
+		if got := s.To.Sub(s.From).Len(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("segment at depth %d is %v long, want %v", s.Depth, got, want)
+		}
+	}
+}
+
+func TestLayoutMeshes(t *testing.T) {
+	tree := BuildTree(4)
+	l := LayoutTree(&tree, squareLayout())
+	color := primitives.Color{Red: 1}
+
+	ms := l.Meshes(color)
+	if len(ms) != 7 {
+		t.Fatalf("got %d meshes, want one per depth", len(ms))
+	}
+	for i, m := range ms {
+		if m.Mode != meshes.DrawModeSegments || len(m.Vertices) != 2 || *m.Color != color {
+			t.Errorf("depth %d mesh %+v, want one colored segment", i+1, m)
+		}
+		// 4 halving each generation, held at 1
+		if want := math.Max(4*math.Pow(0.5, float64(i)), 1); m.Thickness != want {
+			t.Errorf("depth %d thickness %v, want %v", i+1, m.Thickness, want)
+		}
+	}
+	// depth 6 is the twig from 5 up to 10
+	if from, to := ms[5].Vertices[0], ms[5].Vertices[1]; !nearPoint(from, primitives.Float2{X: 4, Y: 1}) || !nearPoint(to, primitives.Float2{X: 4, Y: 2}) {
+		t.Errorf("depth 6 segment %v to %v, want (4, 1) to (4, 2)", from, to)
+	}
+
+	branches := l.BranchMeshes(color)
+	if len(branches) != 1 || branches[0].Mode != meshes.DrawModeLine || len(branches[0].Vertices) != 8 {
+		t.Errorf("branch meshes %+v, want one line through all 8 values", branches)
+	}
+}
+
+// TestLayoutStandsCycleTreesSideBySide lays out 5x+1, whose values below 20 end in two cycles as well as
+// at 1. Every value gets a position, and each root's tree stands clear to the right of the one before
+func TestLayoutStandsCycleTreesSideBySide(t *testing.T) {
+	tree, _ := fivePlusOne(t)
+	opts := squareLayout()
+	l := LayoutTree(&tree, opts)
+
+	for v := range tree.Buds {
+		if _, ok := l.Positions[v]; !ok {
+			t.Errorf("value %d has no position", v)
+		}
+	}
+
+	roots := tree.roots()
+	if len(roots) != 3 {
+		t.Fatalf("got %d roots, want 1 and two cycles", len(roots))
+	}
+	if !nearPoint(l.Positions[roots[0].Value], opts.Origin) {
+		t.Errorf("root %d at %v, want the origin", roots[0].Value, l.Positions[roots[0].Value])
+	}
+	right := math.Inf(-1)
+	for _, root := range roots {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		stack := []*Bud{root}
+		for len(stack) > 0 {
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			lo, hi = min(lo, l.Positions[b.Value].X), max(hi, l.Positions[b.Value].X)
+			for _, twig := range b.Children {
+				stack = append(stack, twig.Child)
+			}
+		}
+		if lo <= right {
+			t.Errorf("tree of %d spans x %v to %v, overlapping the tree before it, which ends at %v",
+				root.Value, lo, hi, right)
+		}
+		right = hi
+	}
+}
@@ -0,0 +1,93 @@
+package collatz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Rule maps a value to the next on its trajectory. Returning less than 1 ends the trajectory there, for
+// values the rule can't map, such as on overflow
+type Rule func(int) int
+
+// QXPlusOne halves even values and sends odd ones to q*n+1. q = 3 is the Collatz map. q must be positive
+// and odd: with an even q, q*n+1 is odd again and trajectories only ever climb
+func QXPlusOne(q int) (Rule, error) {
+	if q < 1 {
+		return nil, fmt.Errorf("collatz: q must be positive, got %d", q)
+	}
+	if q%2 == 0 {
+		return nil, fmt.Errorf("collatz: q must be odd, got %d", q)
+	}
+	return func(n int) int {
+		if n%2 == 0 {
+			return n / 2
+		}
+		if n > (math.MaxInt-1)/q {
+			return 0
+		}
+		return q*n + 1
+	}, nil
+}
+
+// Affine is the branch of a ModRule taking n to (Mul*n + Add) / Div. Add should make the division exact
+// for every n the branch applies to
+type Affine struct {
+	Mul, Add, Div int
+}
+
+// ModRule is a generalized Collatz function in Conway's sense: a value n takes the branch for n mod k,
+// where k is the number of branches. The Collatz map is ModRule(Affine{1, 0, 2}, Affine{3, 1, 1}).
+// It fails without branches or on a branch dividing by zero
+func ModRule(branches ...Affine) (Rule, error) {
+	k := len(branches)
+	if k == 0 {
+		return nil, errors.New("collatz: a ModRule needs at least one branch")
+	}
+	for i, b := range branches {
+		if b.Div == 0 {
+			return nil, fmt.Errorf("collatz: ModRule branch %d divides by zero", i)
+		}
+	}
+	branches = slices.Clone(branches)
+	return func(n int) int {
+		b := branches[n%k]
+		if b.Mul != 0 && (n > (math.MaxInt-max(b.Add, 0))/b.Mul) {
+			return 0
+		}
+		return (b.Mul*n + b.Add) / b.Div
+	}, nil
+}
+
+// Cycle is a loop a rule falls into, starting from its smallest value. Root is the bud its twigs lead to
+// but which has no parent itself, since that twig would close the loop. Entry is the value that twig
+// would lead to, rule(Root.Value), where the trajectory first met the loop
+type Cycle struct {
+	Values []int
+	Root   *Bud
+	Entry  int
+}
+
+func (c Cycle) String() string {
+	parts := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, " → ")
+}
+
+// newCycle rotates the loop to start from its smallest value
+func newCycle(values []int, root *Bud, entry int) Cycle {
+	i := slices.Index(values, slices.Min(values))
+	return Cycle{Values: append(slices.Clone(values[i:]), values[:i]...), Root: root, Entry: entry}
+}
+
+// Divergence is a start whose trajectory was abandoned, at Last, after too many steps or a value the rule
+// couldn't map
+type Divergence struct {
+	Start int
+	Last  int
+	Steps int
+}
@@ -0,0 +1,33 @@
+package collatz
+
+import "testing"
+
+func TestRulesRejectBadParameters(t *testing.T) {
+	for _, q := range []int{0, -3, 2, 4} {
+		if _, err := QXPlusOne(q); err == nil {
+			t.Errorf("QXPlusOne(%d) gave no error", q)
+		}
+	}
+	if _, err := ModRule(); err == nil {
+		t.Error("ModRule without branches gave no error")
+	}
+	if _, err := ModRule(Affine{1, 0, 2}, Affine{3, 1, 0}); err == nil {
+		t.Error("ModRule dividing by zero gave no error")
+	}
+}
+
+func TestModRuleMatchesQXPlusOne(t *testing.T) {
+	collatz, err := QXPlusOne(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := ModRule(Affine{1, 0, 2}, Affine{3, 1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n < 1000; n++ {
+		if got, want := mod(n), collatz(n); got != want {
+			t.Fatalf("ModRule(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package collatz
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// ValueStats describes the trajectory from one value
+type ValueStats struct {
+	Value int `json:"value"`
+	// StoppingTime is the steps until the trajectory first drops below Value, 0 for 1
+	StoppingTime int `json:"stopping_time"`
+	// TotalStoppingTime is the steps to reach 1, the value's depth in the tree
+	TotalStoppingTime int `json:"total_stopping_time"`
+	// MaxAltitude is the largest value on the trajectory, including Value itself
+	MaxAltitude int `json:"max_altitude"`
+	Children    int `json:"children"`
+}
+
+// Record is a start that beats every smaller start on one measure
+type Record struct {
+	Value  int `json:"value"`
+	Amount int `json:"amount"`
+}
+
+// Stats is what Analyze finds in a tree
+type Stats struct {
+	// MaxStart is the largest start considered for the longest chain and records
+	MaxStart int `json:"max_start"`
+	// Values has every value in the tree, ascending
+	Values []ValueStats `json:"values"`
+	// DepthHistogram counts the values at each depth, the root at 0
+	DepthHistogram []int `json:"depth_histogram"`
+	// BranchingHistogram counts the values with each number of children
+	BranchingHistogram []int `json:"branching_histogram"`
+	// LongestChain is the start up to MaxStart with the largest total stopping time, the smallest on ties
+	LongestChain ValueStats `json:"longest_chain"`
+
+	StoppingTimeRecords      []Record `json:"stopping_time_records"`
+	TotalStoppingTimeRecords []Record `json:"total_stopping_time_records"`
+	AltitudeRecords          []Record `json:"altitude_records"`
+}
+
+// Analyze measures every value that reaches the root, so not those in the trees of other cycles. Starts
+// from 1 to maxStart should all be in it; values above are only counted as intermediates. maxStart below
+// 1 considers every value a start. Each twig is one step, so a value's depth is its total stopping time;
+// this is why CompactTree.Organic refuses pruned trees
+func Analyze(t *OrganicTree, maxStart int) Stats {
+	s := Stats{MaxStart: maxStart}
+	if t.Root == nil {
+		return s
+	}
+
+	// depth and altitude flow down from the root
+	type visit struct {
+		bud             *Bud
+		depth, altitude int
+	}
+	stack := []visit{{bud: t.Root, altitude: t.Root.Value}}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		s.Values = append(s.Values, ValueStats{
+			Value:             v.bud.Value,
+			TotalStoppingTime: v.depth,
+			MaxAltitude:       v.altitude,
+			Children:          len(v.bud.Children),
+		})
+		s.DepthHistogram = grow(s.DepthHistogram, v.depth)
+		s.DepthHistogram[v.depth]++
+		s.BranchingHistogram = grow(s.BranchingHistogram, len(v.bud.Children))
+		s.BranchingHistogram[len(v.bud.Children)]++
+
+		for _, twig := range v.bud.Children {
+			stack = append(stack, visit{bud: twig.Child, depth: v.depth + 1, altitude: max(v.altitude, twig.Child.Value)})
+		}
+	}
+
+	// stopping time walks up the tree to the first smaller value
+	for i := range s.Values {
+		vs := &s.Values[i]
+		bud := t.Buds[vs.Value]
+		for bud.Parent != nil {
+			bud = bud.Parent.Parent
+			vs.StoppingTime++
+			if bud.Value < vs.Value {
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(s.Values, func(a, b ValueStats) int { return a.Value - b.Value })
+
+	// longest chain and records, in order of start
+	bestStop, bestTotal, bestAltitude := -1, -1, -1
+	for _, vs := range s.Values {
+		if maxStart >= 1 && vs.Value > maxStart {
+			break
+		}
+		if vs.TotalStoppingTime > s.LongestChain.TotalStoppingTime || s.LongestChain.Value == 0 {
+			s.LongestChain = vs
+		}
+		if vs.StoppingTime > bestStop {
+			bestStop = vs.StoppingTime
+			s.StoppingTimeRecords = append(s.StoppingTimeRecords, Record{vs.Value, vs.StoppingTime})
+		}
+		if vs.TotalStoppingTime > bestTotal {
+			bestTotal = vs.TotalStoppingTime
+			s.TotalStoppingTimeRecords = append(s.TotalStoppingTimeRecords, Record{vs.Value, vs.TotalStoppingTime})
+		}
+		if vs.MaxAltitude > bestAltitude {
+			bestAltitude = vs.MaxAltitude
+			s.AltitudeRecords = append(s.AltitudeRecords, Record{vs.Value, vs.MaxAltitude})
+		}
+	}
+	return s
+}
+
+// grow extends a histogram so i is in range
+func grow(h []int, i int) []int {
+	for len(h) <= i {
+		h = append(h, 0)
+	}
+	return h
+}
+
+// Lookup returns the measures for value, if it is in the tree
+func (s *Stats) Lookup(value int) (ValueStats, bool) {
+	i, ok := slices.BinarySearchFunc(s.Values, value, func(vs ValueStats, v int) int { return vs.Value - v })
+	if !ok {
+		return ValueStats{}, false
+	}
+	return s.Values[i], true
+}
+
+// WriteCSV writes one row per value, with a header
+func (s *Stats) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"value", "stopping_time", "total_stopping_time", "max_altitude", "children"})
+	for _, vs := range s.Values {
+		cw.Write([]string{
+			strconv.Itoa(vs.Value),
+			strconv.Itoa(vs.StoppingTime),
+			strconv.Itoa(vs.TotalStoppingTime),
+			strconv.Itoa(vs.MaxAltitude),
+			strconv.Itoa(vs.Children),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes everything, indented
+func (s *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,75 @@
+package collatz
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeKnownTrajectories(t *testing.T) {
+	tree := BuildTree(30)
+	stats := Analyze(&tree, 30)
+
+	for _, want := range []ValueStats{
+		{Value: 1, StoppingTime: 0, TotalStoppingTime: 0, MaxAltitude: 1},
+		{Value: 2, StoppingTime: 1, TotalStoppingTime: 1, MaxAltitude: 2},
+		{Value: 3, StoppingTime: 6, TotalStoppingTime: 7, MaxAltitude: 16},
+		{Value: 7, StoppingTime: 11, TotalStoppingTime: 16, MaxAltitude: 52},
+		{Value: 27, StoppingTime: 96, TotalStoppingTime: 111, MaxAltitude: 9232},
+	} {
+		got, ok := stats.Lookup(want.Value)
+		if !ok {
+			t.Errorf("%d is not in the tree", want.Value)
+			continue
+		}
+		got.Children = 0
+		if got != want {
+			t.Errorf("Lookup(%d) = %+v, want %+v", want.Value, got, want)
+		}
+	}
+
+	if stats.LongestChain.Value != 27 {
+		t.Errorf("longest chain starts at %d, want 27", stats.LongestChain.Value)
+	}
+
+	records := func(rs []Record) []int {
+		vs := make([]int, len(rs))
+		for i, r := range rs {
+			vs[i] = r.Value
+		}
+		return vs
+	}
+	if got, want := records(stats.TotalStoppingTimeRecords), []int{1, 2, 3, 6, 7, 9, 18, 25, 27}; !slices.Equal(got, want) {
+		t.Errorf("total stopping time records %v, want %v", got, want)
+	}
+	if got, want := records(stats.StoppingTimeRecords), []int{1, 2, 3, 7, 27}; !slices.Equal(got, want) {
+		t.Errorf("stopping time records %v, want %v", got, want)
+	}
+	if got, want := records(stats.AltitudeRecords), []int{1, 2, 3, 7, 15, 27}; !slices.Equal(got, want) {
+		t.Errorf("altitude records %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeDepthHistogram(t *testing.T) {
+	tree := BuildTree(30)
+	stats := Analyze(&tree, 30)
+
+	// the tree's trunk: 1 ← 2 ← 4 ← 8 ← 16, then 32 and 5, then 64 and 10
+	if got, want := stats.DepthHistogram[:7], []int{1, 1, 1, 1, 1, 2, 2}; !slices.Equal(got, want) {
+		t.Errorf("depth histogram starts %v, want %v", got, want)
+	}
+	if got := len(stats.DepthHistogram) - 1; got != 111 {
+		t.Errorf("deepest value at %d, want 111 for 27", got)
+	}
+
+	total, children := 0, 0
+	for _, n := range stats.DepthHistogram {
+		total += n
+	}
+	for k, n := range stats.BranchingHistogram {
+		children += k * n
+	}
+	if total != len(stats.Values) || children != len(stats.Values)-1 {
+		t.Errorf("histograms count %d values and %d twigs, want %d and %d",
+			total, children, len(stats.Values), len(stats.Values)-1)
+	}
+}
@@ -61,7 +61,7 @@ func runOld() {
 
 	scopeParams := sources.ScopeParams{
 		Gain:  1.0,
-		Decay: 0.96,
+		Decay: 0.25,
 		Fx:    3.0,
 		Fy:    2.0,
 		Phase: 0.0,
@@ -71,21 +71,35 @@ func runOld() {
 
 	renderer := renderers.OscilloscopeRenderer{
 		Params: &scopeParams,
+		Mode:   renderers.OscilloscopeXYRenderer,
+		Time:   renderers.NewTimeScope(0.1, 0.25),
 	}
 
-	engine := engines.New(
+	engine, err := engines.New(
 		source,
 		engines.WithSampleRate(float64(rate)),
 	)
+	if err != nil {
+		panic(err)
+	}
 
 	for !screen.Window().Closed() {
 		dt := screen.DT()
 		handleInput(screen.Window(), &scopeParams, dt)
 
+		// M toggles between the XY figure and a Y-T sweep
+		if screen.Window().JustPressed(pixel.KeyM) {
+			if renderer.Mode == renderers.OscilloscopeTimeRenderer {
+				renderer.Mode = renderers.OscilloscopeXYRenderer
+			} else {
+				renderer.Mode = renderers.OscilloscopeTimeRenderer
+			}
+		}
+
 		engine.Step(dt)
 
 		renderer.BeginFrame(dt)
-		renderer.Draw(engine.Samples())
+		renderer.Draw(engine.Samples(engines.DefaultChannel))
 		renderer.EndFrame(screen.Window())
 
 		screen.Present()
@@ -105,7 +119,7 @@ func run() {
 
 	scopeParams := sources.ScopeParams{
 		Gain:  1.0,
-		Decay: 0.96,
+		Decay: 0.25,
 		Fx:    3.0,
 		Fy:    2.0,
 		Phase: 0.0,
@@ -113,10 +127,13 @@ func run() {
 
 	source := sources.NewLissajous(&scopeParams, float64(rate))
 
-	engine := engines.New(
+	engine, err := engines.New(
 		source,
 		engines.WithSampleRate(float64(rate)),
 	)
+	if err != nil {
+		panic(err)
+	}
 
 	// --- rendering setup ---
 	tileW := 240.0
@@ -146,17 +163,18 @@ func run() {
 		handleInput(screen.Window(), &scopeParams, dt)
 
 		engine.Step(dt)
-		samples := engine.Samples()
+		samples := engine.Samples(engines.DefaultChannel)
 
 		mesh := meshes.BuildOscilloscopeMesh(samples, &scopeParams, tileW, tileH)
 		meshRegistry.Update(oscMeshID, mesh)
 
 		fc := &renderers.FrameContext{
-			Target:  screen.Window(),
-			Time:    time.Since(start).Seconds(),
-			Delta:   dt,
-			Size:    screen.Window().Bounds().Size(),
-			Samples: samples,
+			Target:   screen.Window(),
+			Time:     time.Since(start).Seconds(),
+			Delta:    dt,
+			Size:     screen.Window().Bounds().Size(),
+			Samples:  samples,
+			Channels: engine.ChannelSamples(),
 		}
 
 		renderer.Render(fc)
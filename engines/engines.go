@@ -2,60 +2,233 @@
 package engines
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/mykeelium/visual-playground/renderers"
 	"github.com/mykeelium/visual-playground/sources"
 )
 
+// DefaultChannel is the name New registers its source under
+const DefaultChannel = "default"
+
 type Engine struct {
-	Source      sources.Source
-	Render      renderers.Renderer
-	RateHz      float64
+	Render renderers.Renderer
+	// RateHz is the sample rate for channels that do not set their own
+	RateHz float64
+	// MaxSamplesPerStep caps how many samples one Step produces per channel; after a long frame stall the
+	// backlog beyond the cap is dropped rather than emitted all at once. Zero disables the cap
+	MaxSamplesPerStep int
+
+	channels []*Channel
+	byName   map[string]*Channel
+
+	// stepped time in nanoseconds, kept as an integer so the sample clocks never drift
+	clockNs int64
+}
+
+// Channel is one named source with its own sample rate and buffer, stepped together with the others
+type Channel struct {
+	Name   string
+	Source sources.Source
+	// RateHz overrides the engine rate when non-zero
+	RateHz float64
+
 	buffer      []sources.Sample
 	bufferCount int
-	tAcc        float64
+
+	// number of samples emitted since the current rate took effect at epochNs
+	rate    float64
+	epochNs int64
+	emitted int64
 }
 
-type Option func(*Engine)
+type Option func(*Engine) error
 
 func WithSampleRate(rate float64) Option {
-	return func(e *Engine) {
+	return func(e *Engine) error {
 		e.RateHz = rate
+		return nil
 	}
 }
 
-func New(source sources.Source, opts ...Option) *Engine {
+func WithMaxSamplesPerStep(n int) Option {
+	return func(e *Engine) error {
+		e.MaxSamplesPerStep = n
+		return nil
+	}
+}
+
+// WithChannel registers an extra channel; a rate of 0 follows the engine rate. New fails on a duplicate name
+func WithChannel(name string, source sources.Source, rate float64) Option {
+	return func(e *Engine) error {
+		_, err := e.AddChannel(name, source, rate)
+		return err
+	}
+}
+
+// New creates an engine; a non-nil source is registered as DefaultChannel. It fails if an option does
+func New(source sources.Source, opts ...Option) (*Engine, error) {
 	e := &Engine{
-		Source: source,
-		RateHz: 120000,
-		buffer: make([]sources.Sample, 0, 8192),
+		RateHz:            120000,
+		MaxSamplesPerStep: 1 << 16,
+		byName:            map[string]*Channel{},
+	}
+
+	if source != nil {
+		e.AddChannel(DefaultChannel, source, 0)
 	}
 
 	for _, opt := range opts {
-		opt(e)
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// AddChannel registers a source under name, starting at the current engine time. Sample times are engine
+// time, so a source added after the engine has stepped is sought there. A rate of 0 follows the engine rate
+func (e *Engine) AddChannel(name string, source sources.Source, rate float64) (*Channel, error) {
+	if _, exists := e.byName[name]; exists {
+		return nil, fmt.Errorf("engines: channel %q already exists", name)
+	}
+	if e.clockNs > 0 {
+		source.Seek(e.Time())
 	}
 
-	return e
+	ch := &Channel{
+		Name:    name,
+		Source:  source,
+		RateHz:  rate,
+		buffer:  make([]sources.Sample, 0, 8192),
+		epochNs: e.clockNs,
+	}
+	e.channels = append(e.channels, ch)
+	e.byName[name] = ch
+	return ch, nil
+}
+
+func (e *Engine) RemoveChannel(name string) {
+	if _, exists := e.byName[name]; !exists {
+		return
+	}
+	delete(e.byName, name)
+	for i, ch := range e.channels {
+		if ch.Name == name {
+			e.channels = append(e.channels[:i], e.channels[i+1:]...)
+			break
+		}
+	}
+}
+
+func (e *Engine) Channel(name string) *Channel {
+	return e.byName[name]
+}
+
+// Channels returns the channel names in registration order
+func (e *Engine) Channels() []string {
+	names := make([]string, len(e.channels))
+	for i, ch := range e.channels {
+		names[i] = ch.Name
+	}
+	return names
+}
+
+// SetSampleRate changes a channel's rate without a discontinuity: the new rate starts at the next sample.
+// Assigning Channel.RateHz or Engine.RateHz directly has the same effect on the following Step
+func (e *Engine) SetSampleRate(channel string, rate float64) {
+	ch := e.byName[channel]
+	if ch == nil || rate <= 0 {
+		return
+	}
+	ch.RateHz = rate
+	e.applyRate(ch)
+}
+
+func (e *Engine) channelRate(ch *Channel) float64 {
+	if ch.RateHz > 0 {
+		return ch.RateHz
+	}
+	return e.RateHz
+}
+
+func (e *Engine) applyRate(ch *Channel) {
+	rate := e.channelRate(ch)
+	if rate == ch.rate || rate <= 0 {
+		return
+	}
+
+	if ch.rate > 0 {
+		// start a new epoch at the time of the next sample under the old rate
+		ch.epochNs += int64(math.Round(float64(ch.emitted) * 1e9 / ch.rate))
+		ch.emitted = 0
+	}
+	ch.rate = rate
+
+	if rs, ok := ch.Source.(sources.RateSetter); ok {
+		rs.SetRate(rate)
+	}
 }
 
 func (e *Engine) Step(dt float64) {
-	e.Source.Update(dt)
+	e.clockNs += int64(math.Round(dt * 1e9))
 
-	// produces samples at a fixed sample rate independent of FPS
-	e.tAcc += dt
-	want := int(e.tAcc * e.RateHz)
+	for _, ch := range e.channels {
+		ch.Source.Update(dt)
+		e.applyRate(ch)
+		e.stepChannel(ch)
+	}
+}
+
+// stepChannel produces samples at a fixed sample rate independent of FPS
+func (e *Engine) stepChannel(ch *Channel) {
+	target := int64(float64(e.clockNs-ch.epochNs) * ch.rate / 1e9)
+	want := target - ch.emitted
 	if want <= 0 {
-		e.bufferCount = 0
+		ch.bufferCount = 0
 		return
 	}
-	e.tAcc -= float64(want) / e.RateHz
+	if e.MaxSamplesPerStep > 0 && want > int64(e.MaxSamplesPerStep) {
+		// skip the backlog instead of trying to catch up, moving the source past it so its sample times
+		// stay on engine time
+		ch.emitted += want - int64(e.MaxSamplesPerStep)
+		want = int64(e.MaxSamplesPerStep)
+		ch.Source.Seek(float64(ch.epochNs)/1e9 + float64(ch.emitted)/ch.rate)
+	}
+	ch.emitted += want
+
+	if cap(ch.buffer) < int(want) {
+		ch.buffer = make([]sources.Sample, want)
+	}
+	ch.buffer = ch.buffer[:want]
+	ch.bufferCount = ch.Source.Emit(int(want), ch.buffer)
+}
+
+// Time is the engine clock in seconds, as stepped so far
+func (e *Engine) Time() float64 {
+	return float64(e.clockNs) / 1e9
+}
 
-	if cap(e.buffer) < want {
-		e.buffer = make([]sources.Sample, want)
+// Samples returns the samples produced by the last Step for a channel, or nil if it does not exist
+func (e *Engine) Samples(channel string) []sources.Sample {
+	ch := e.byName[channel]
+	if ch == nil {
+		return nil
 	}
-	e.buffer = e.buffer[:want]
-	e.bufferCount = e.Source.Emit(want, e.buffer)
+	return ch.Samples()
 }
 
-func (e *Engine) Samples() []sources.Sample {
-	return e.buffer[:e.bufferCount]
+func (ch *Channel) Samples() []sources.Sample {
+	return ch.buffer[:ch.bufferCount]
+}
+
+// ChannelSamples returns the last Step's samples of every channel by name, e.g. for FrameContext.Channels
+func (e *Engine) ChannelSamples() map[string][]sources.Sample {
+	out := make(map[string][]sources.Sample, len(e.channels))
+	for _, ch := range e.channels {
+		out[ch.Name] = ch.Samples()
+	}
+	return out
 }
@@ -0,0 +1,92 @@
+package engines
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+// checkSine asserts samples follow a sine at freq Hz, one every 1/rate seconds from start
+func checkSine(t *testing.T, name string, samples []sources.Sample, freq, rate, start float64, want int) {
+	t.Helper()
+	if len(samples) != want {
+		t.Fatalf("%s channel has %d samples, want %d", name, len(samples), want)
+	}
+	for i, s := range samples {
+		wantT := start + float64(i)/rate
+		if math.Abs(s.T-wantT) > 1e-9 {
+			t.Fatalf("%s sample %d at T %v, want %v", name, i, s.T, wantT)
+		}
+		if wantV := math.Sin(2 * math.Pi * freq * wantT); math.Abs(s.V-wantV) > 1e-9 {
+			t.Fatalf("%s sample %d = %v, want %v", name, i, s.V, wantV)
+		}
+	}
+}
+
+func TestChannelsStepTogether(t *testing.T) {
+	e, err := New(
+		sources.NewSine(100, 1, 0),
+		WithSampleRate(1000),
+		WithChannel("slow", sources.NewSine(50, 1, 0), 500),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Step(0.1)
+	checkSine(t, DefaultChannel, e.Samples(DefaultChannel), 100, 1000, 0, 100)
+	checkSine(t, "slow", e.Samples("slow"), 50, 500, 0, 50)
+
+	// both channels pick up at the same time after a rate change
+	e.SetSampleRate(DefaultChannel, 2000)
+	e.Step(0.1)
+	checkSine(t, DefaultChannel, e.Samples(DefaultChannel), 100, 2000, 0.1, 200)
+	checkSine(t, "slow", e.Samples("slow"), 50, 500, 0.1, 50)
+
+	e.Channel("slow").RateHz = 250
+	e.Step(0.1)
+	checkSine(t, DefaultChannel, e.Samples(DefaultChannel), 100, 2000, 0.2, 200)
+	checkSine(t, "slow", e.Samples("slow"), 50, 250, 0.2, 25)
+}
+
+func TestDuplicateChannelFails(t *testing.T) {
+	_, err := New(sources.NewSine(100, 1, 0), WithChannel(DefaultChannel, sources.NewSine(50, 1, 0), 0))
+	if err == nil {
+		t.Fatal("New accepted a second channel named like the default one")
+	}
+}
+
+func TestStallKeepsSamplesOnEngineTime(t *testing.T) {
+	e, err := New(sources.NewSine(100, 1, 0), WithSampleRate(1000), WithMaxSamplesPerStep(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Step(0.01)
+
+	// a 1 s frame hitch, far past the cap, drops the backlog
+	e.Step(1)
+	stalled := e.Samples(DefaultChannel)
+	checkSine(t, "stalled", stalled, 100, 1000, e.Time()-0.05, 50)
+
+	stallEnd := e.Time()
+	e.Step(0.01)
+	if first := e.Samples(DefaultChannel)[0]; math.Abs(first.T-stallEnd) > 1e-9 {
+		t.Errorf("first sample after the stall at T %v, want the engine time %v", first.T, stallEnd)
+	}
+}
+
+func TestChannelAddedMidRunUsesEngineTime(t *testing.T) {
+	e, err := New(sources.NewSine(100, 1, 0), WithSampleRate(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Step(0.25)
+
+	if _, err := e.AddChannel("late", sources.NewSine(50, 1, 0), 0); err != nil {
+		t.Fatal(err)
+	}
+	e.Step(0.1)
+	checkSine(t, DefaultChannel, e.Samples(DefaultChannel), 100, 1000, 0.25, 100)
+	checkSine(t, "late", e.Samples("late"), 50, 1000, 0.25, 100)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	// "fmt"
-	"math"
 	"math/rand"
 	"time"
 
@@ -23,52 +22,21 @@ var (
 		MaxY: height,
 		MaxX: width,
 	}
-	circles []*primitives.Entity
+	world = primitives.NewWorld(worldBounds, gravity)
+	rng   = rand.New(rand.NewSource(42))
 )
 
 const (
-	width        float64 = 2048
-	height       float64 = 1024
-	floor        float64 = 0
-	resititution float64 = 0.6
-	friction     float64 = 0.95
+	width           float64 = 2048
+	height          float64 = 1024
+	floor           float64 = 0
+	resititution    float64 = 0.6
+	contactFriction float64 = 0.3 // Coulomb coefficient, not the old per-bounce velocity damping
 )
 
-func CreateChaoticCircles(
-	count int,
-	radius float64,
-	windowWidth float64,
-	windowHeight float64,
-	speedMin float64,
-	speedMax float64,
-) []*primitives.Entity {
-	circles = []*primitives.Entity{}
-
-	for range count {
-		x := rand.Float64()*(windowWidth-2*radius) + radius
-		y := rand.Float64()*(windowHeight-2*radius) + radius
-
-		e := primitives.NewCircleEntity(x, y, radius, 0,
-			rand.Float64(), // red
-			rand.Float64(), // green
-			rand.Float64(), // blue
-		)
-
-		speed := speedMin + rand.Float64()*(speedMax-speedMin)
-		angle := rand.Float64() * 2 * math.Pi
-		vx := math.Cos(angle) * speed
-		vy := math.Sin(angle) * speed
-
-		e.Physics.Velocity = primitives.Float2(pixel.V(vx, vy))
-
-		circles = append(circles, e)
-	}
-
-	return circles
-}
-
 func ResetSimulation() {
-	circles = CreateChaoticCircles(
+	world.Reset(primitives.CreateChaoticCircles(
+		rng,
 		1000, // count
 		5,    // radius
 		width,
@@ -76,44 +44,21 @@ func ResetSimulation() {
 
 		50,  // min speed
 		200, // max speed
-	)
+	))
+
+	// the mouse force flings circles fast enough to pass through each other between steps
+	for _, e := range world.Entities {
+		e.Collider.CCD = true
+	}
 }
 
 func main() {
 	// tree := collatz.BuildTree(100)
 	// fmt.Println("tree:")
-	// collatz.PrintOrganicTree(&tree)
-	rand.Seed(42)
+	// collatz.PrintOrganicTree(os.Stdout, &tree)
 	opengl.Run(run)
 }
 
-func drawEntities(imd *imdraw.IMDraw, entities []*primitives.Entity) {
-	for _, e := range entities {
-		e.Draw(imd)
-	}
-}
-
-func updateEntities(entities []*primitives.Entity, grid *primitives.SpatialGrid, dt float64) {
-	grid.Clear()
-
-	for _, e := range entities {
-		e.ApplyGravity(gravity)
-		e.Update(dt)
-		e.HandleBoundaryCollisions(worldBounds, resititution, friction)
-		e.UpdateColorBasedOnSpeed(800)
-
-		grid.Insert(e)
-	}
-
-	// Multiple iteration to try and handle all collisions
-
-	// for range 4 {
-	for _, e := range entities {
-		e.HandleObjectCollisions(grid)
-	}
-	//}
-}
-
 func run() {
 	cfg := opengl.WindowConfig{
 		Title:  "Pixel Rocks!",
@@ -127,10 +72,9 @@ func run() {
 	}
 
 	imd := imdraw.New(nil)
-	grid := &primitives.SpatialGrid{
-		CellSize: 25,
-		Buckets:  map[[2]int][]*primitives.Entity{},
-	}
+	world.Contacts.Default.Restitution = resititution
+	world.Contacts.Default.StaticFriction = contactFriction
+	world.Contacts.Default.DynamicFriction = contactFriction
 	ResetSimulation()
 
 	last := time.Now()
@@ -149,23 +93,26 @@ func run() {
 		dt := now.Sub(last).Seconds()
 		last = now
 
+		// the mouse force is a field so it is applied every fixed step rather than once per frame
+		world.Fields = world.Fields[:0]
 		if win.Pressed(pixel.MouseButtonLeft) {
-			mousePosition := win.MousePosition()
-			primitives.ApplyCircularForce(true, circles, mousePosition, 300, 5000, dt)
+			world.Fields = append(world.Fields, primitives.CircularField(primitives.Float2(win.MousePosition()), 300, 5000))
 		}
 
 		if win.Pressed(pixel.MouseButtonRight) {
-			mousePosition := win.MousePosition()
-			primitives.ApplyCircularForce(false, circles, mousePosition, 300, 5000, dt)
+			world.Fields = append(world.Fields, primitives.CircularField(primitives.Float2(win.MousePosition()), 300, -5000))
 		}
 
 		// Update Simulation
-		updateEntities(circles, grid, dt)
+		world.Advance(dt)
+		for _, e := range world.Entities {
+			e.UpdateColorBasedOnSpeed(800)
+		}
 
 		// Draw
 		imd.Clear()
 		win.Clear(colornames.Black)
-		drawEntities(imd, circles)
+		world.Draw(imd)
 		imd.Draw(win)
 		win.Update()
 	}
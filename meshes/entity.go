@@ -0,0 +1,49 @@
+package meshes
+
+import "github.com/mykeelium/visual-playground/primitives"
+
+// BuildEntityMesh turns an entity into a mesh so it can be drawn by any RenderBackend. Circles become a
+// single filled point, boxes and polygons a closed outline and segments a line. Other renders produce an
+// empty mesh
+func BuildEntityMesh(e *primitives.Entity) Mesh {
+	return BuildEntityMeshAt(e, e.Physics.Position, e.Physics.Angle)
+}
+
+// BuildEntityMeshAt is BuildEntityMesh with the entity placed at p and rotated by angle, such as an
+// interpolated pose from primitives.World
+func BuildEntityMeshAt(e *primitives.Entity, p primitives.Float2, angle float64) Mesh {
+	switch render := e.Render.(type) {
+	case *primitives.CircleRender:
+		c := render.Color
+		return Mesh{
+			Vertices:  []primitives.Float2{p},
+			Mode:      DrawModePoint,
+			Color:     &c,
+			Thickness: 2 * render.Radius,
+		}
+	case *primitives.BoxRender:
+		return outline(p, angle, primitives.BoxVertices(render.HalfExtents), render.Color, render.Thickness)
+	case *primitives.PolygonRender:
+		return outline(p, angle, render.Vertices, render.Color, render.Thickness)
+	case *primitives.SegmentRender:
+		c := render.Color
+		return Mesh{
+			Vertices:  []primitives.Float2{p.Add(render.A.Rotated(angle)), p.Add(render.B.Rotated(angle))},
+			Mode:      DrawModeLine,
+			Color:     &c,
+			Thickness: render.Thickness,
+		}
+	}
+	return Mesh{Mode: DrawModePoint}
+}
+
+func outline(p primitives.Float2, angle float64, vertices []primitives.Float2, c primitives.Color, thickness float64) Mesh {
+	pts := make([]primitives.Float2, 0, len(vertices)+1)
+	for _, v := range vertices {
+		pts = append(pts, p.Add(v.Rotated(angle)))
+	}
+	if len(pts) > 0 {
+		pts = append(pts, pts[0])
+	}
+	return Mesh{Vertices: pts, Mode: DrawModeLine, Color: &c, Thickness: thickness}
+}
@@ -0,0 +1,35 @@
+package meshes
+
+import "github.com/mykeelium/visual-playground/primitives"
+
+// BuildGraticuleMesh builds an oscilloscope grid of divX by divY divisions as segments, with minor ticks
+// every fifth of a division along the centre axes
+func BuildGraticuleMesh(width, height float64, divX, divY int) Mesh {
+	pts := []primitives.Float2{}
+	line := func(x0, y0, x1, y1 float64) {
+		pts = append(pts, primitives.Float2{X: x0, Y: y0}, primitives.Float2{X: x1, Y: y1})
+	}
+
+	dx := width / float64(divX)
+	dy := height / float64(divY)
+	for i := 0; i <= divX; i++ {
+		line(float64(i)*dx, 0, float64(i)*dx, height)
+	}
+	for i := 0; i <= divY; i++ {
+		line(0, float64(i)*dy, width, float64(i)*dy)
+	}
+
+	cx, cy := width/2, height/2
+	tick := min(dx, dy) / 10
+	for i := 0; i <= divX*5; i++ {
+		x := float64(i) * dx / 5
+		line(x, cy-tick, x, cy+tick)
+	}
+	for i := 0; i <= divY*5; i++ {
+		y := float64(i) * dy / 5
+		line(cx-tick, y, cx+tick, y)
+	}
+
+	grey := primitives.Color{Red: 0.3, Green: 0.3, Blue: 0.3}
+	return Mesh{Vertices: pts, Mode: DrawModeSegments, Color: &grey}
+}
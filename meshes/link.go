@@ -0,0 +1,13 @@
+package meshes
+
+import "github.com/mykeelium/visual-playground/primitives"
+
+// BuildLinkMesh turns a constraint into a segment between its anchors
+func BuildLinkMesh(c primitives.Constraint, color primitives.Color) Mesh {
+	a, b := c.Anchors()
+	return Mesh{
+		Vertices: []primitives.Float2{a, b},
+		Mode:     DrawModeSegments,
+		Color:    &color,
+	}
+}
@@ -5,11 +5,19 @@ import "github.com/mykeelium/visual-playground/primitives"
 type MeshID uint32
 type DrawMode string
 
-var DrawModeLine DrawMode = "line"
+var (
+	DrawModeLine  DrawMode = "line"
+	DrawModePoint DrawMode = "point"
+	// DrawModeSegments draws independent lines between each pair of vertices
+	DrawModeSegments DrawMode = "segments"
+)
 
 type Mesh struct {
 	Vertices []primitives.Float2
 	Mode     DrawMode
+	// Color and Thickness override the backend defaults when set; for points Thickness is the diameter
+	Color     *primitives.Color
+	Thickness float64
 }
 
 type MeshRegistry struct {
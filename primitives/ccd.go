@@ -0,0 +1,177 @@
+package primitives
+
+import "math"
+
+// ccdSkin is how far short of the time of impact a swept circle stops, so it isn't left touching
+const ccdSkin = 0.01
+
+// sweep is a CCD entity's position before integration
+type sweep struct {
+	entity *Entity
+	from   Float2
+}
+
+// impact is the earliest hit along a sweep: t is the fraction of the motion travelled and normal points
+// from the other entity towards the swept circle
+type impact struct {
+	t      float64
+	normal Float2
+	other  *Entity
+}
+
+// startSweeps records where every CCD circle starts the substep
+func startSweeps(entities []*Entity, sweeps []sweep) []sweep {
+	sweeps = sweeps[:0]
+	for _, e := range entities {
+		if e.Collider == nil || !e.Collider.CCD || e.IsStatic() {
+			continue
+		}
+		if _, ok := e.Collider.Shape.(*CircleShape); ok {
+			sweeps = append(sweeps, sweep{entity: e, from: e.Physics.Position})
+		}
+	}
+	return sweeps
+}
+
+// region is the box the circle sweeps through, from its start to where it is now
+func (s sweep) region(radius float64) AABB {
+	to := s.entity.Physics.Position
+	r := Float2{X: radius, Y: radius}
+	return AABB{
+		Min: Float2{X: min(s.from.X, to.X), Y: min(s.from.Y, to.Y)}.Sub(r),
+		Max: Float2{X: max(s.from.X, to.X), Y: max(s.from.Y, to.Y)}.Add(r),
+	}
+}
+
+// resolveSweeps moves each swept circle back to its first time of impact along the substep's motion and
+// applies the contact there, so it bounces instead of passing through. Impacts are solved on relative
+// motion: two swept circles are both moved back to the time they meet, while everything else is taken
+// at its end position. Reports whether any circle moved, in which case the grid is stale
+func resolveSweeps(sweeps []sweep, grid *SpatialGrid, rules *ContactRules) bool {
+	// the grid only holds where swept circles end, so they find each other by the space they sweep
+	starts := make(map[*Entity]Float2, len(sweeps))
+	swept := NewSpatialGrid(grid.CellSize)
+	for _, s := range sweeps {
+		starts[s.entity] = s.from
+		swept.insert(s.entity, s.region(s.entity.Collider.Shape.(*CircleShape).Radius))
+	}
+
+	moved := false
+	for _, s := range sweeps {
+		e := s.entity
+		radius := e.Collider.Shape.(*CircleShape).Radius
+		motion := e.Physics.Position.Sub(s.from)
+		if motion.Len() <= radius/2 {
+			// too short to skip past anything the discrete pass would miss; a faster circle sweeping
+			// into this one still finds it
+			continue
+		}
+
+		hit := impact{t: math.Inf(1)}
+		var hitFrom, hitMotion Float2
+		test := func(other *Entity) {
+			if other == e || other.Collider == nil {
+				return
+			}
+			from, otherMotion := other.Physics.Position, Float2{}
+			if start, ok := starts[other]; ok {
+				from, otherMotion = start, other.Physics.Position.Sub(start)
+			}
+			if t, normal, ok := timeOfImpact(s.from, motion.Sub(otherMotion), radius, other, from); ok && t < hit.t {
+				hit = impact{t: t, normal: normal, other: other}
+				hitFrom, hitMotion = from, otherMotion
+			}
+		}
+		region := s.region(radius)
+		swept.Query(region, test)
+		grid.Query(region, func(other *Entity) {
+			if _, ok := starts[other]; !ok {
+				test(other)
+			}
+		})
+		if hit.other == nil {
+			continue
+		}
+
+		t := max(0, hit.t-ccdSkin/motion.Sub(hitMotion).Len())
+		e.Physics.Position = s.from.Add(motion.Scale(t))
+		hit.other.Physics.Position = hitFrom.Add(hitMotion.Scale(t))
+		point := e.Physics.Position.Sub(hit.normal.Scale(radius))
+		applyContactImpulse(hit.other.Physics, e.Physics, hit.normal, []Float2{point}, rules.combine(hit.other, e))
+		moved = true
+	}
+	return moved
+}
+
+// timeOfImpact sweeps a circle from p along motion, relative to the other entity, against the other's
+// collider placed at from. Shapes already overlapping at the start are left to the discrete pass
+func timeOfImpact(p, motion Float2, radius float64, other *Entity, from Float2) (float64, Float2, bool) {
+	q := from
+	if c, ok := other.Collider.Shape.(*CircleShape); ok {
+		return sweepCircle(p, motion, q, radius+c.Radius)
+	}
+
+	poly, ok := polygonOf(other.Collider.Shape, q, other.Physics.Angle)
+	if !ok {
+		return 0, Float2{}, false
+	}
+	if _, _, overlapping := collidePolygonCircle(poly, p, radius); overlapping {
+		return 0, Float2{}, false
+	}
+
+	best, normal, found := math.Inf(1), Float2{}, false
+	for i, n := range poly.normals {
+		v1 := poly.vertices[i]
+		v2 := poly.vertices[(i+1)%len(poly.vertices)]
+
+		// the face, pushed out by the radius
+		gap := n.Dot(p.Sub(v1)) - radius
+		closing := -n.Dot(motion)
+		if gap < 0 || closing <= 0 || gap > closing {
+			continue
+		}
+		t := gap / closing
+		touch := p.Add(motion.Scale(t)).Sub(n.Scale(radius))
+		edge := v2.Sub(v1)
+		if u := touch.Sub(v1).Dot(edge) / edge.Dot(edge); u < 0 || u > 1 {
+			continue
+		}
+		if t < best {
+			best, normal, found = t, n, true
+		}
+	}
+
+	// the corners
+	for _, v := range poly.vertices {
+		if t, n, ok := sweepCircle(p, motion, v, radius); ok && t < best {
+			best, normal, found = t, n, true
+		}
+	}
+	return best, normal, found
+}
+
+// sweepCircle finds when a point moving from p along motion first comes within reach of centre
+func sweepCircle(p, motion, centre Float2, reach float64) (float64, Float2, bool) {
+	offset := p.Sub(centre)
+	a := motion.Dot(motion)
+	b := 2 * motion.Dot(offset)
+	c := offset.Dot(offset) - reach*reach
+	if c <= 0 || a == 0 {
+		return 0, Float2{}, false
+	}
+
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, Float2{}, false
+	}
+	t := (-b - math.Sqrt(disc)) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0, Float2{}, false
+	}
+
+	normal := p.Add(motion.Scale(t)).Sub(centre)
+	if l := normal.Len(); l > 0 {
+		normal = normal.Scale(1 / l)
+	}
+	return t, normal, true
+}
@@ -0,0 +1,45 @@
+package primitives
+
+import "testing"
+
+// ccdWorld steps once per 1/60 s with no gravity, so fast circles cross a lot of space in one step
+func ccdWorld(entities ...*Entity) *World {
+	w := NewWorld(WorldBounds{MinX: -1000, MinY: -1000, MaxX: 1000, MaxY: 1000}, ZeroFloat2)
+	w.FixedDT = 1.0 / 60
+	w.Add(entities...)
+	return w
+}
+
+func fastCircle(x, vx float64) *Entity {
+	e := NewCircleEntity(x, 0, 2, 0, 1, 1, 1)
+	e.Physics.Velocity = Float2{X: vx}
+	e.Collider.CCD = true
+	return e
+}
+
+func TestCCDBouncesOffThinWall(t *testing.T) {
+	wall := NewSegmentObstacle(Float2{X: 10, Y: -50}, Float2{X: 10, Y: 50}, 1, 1, 1, 1)
+	ball := fastCircle(0, 1200)
+
+	ccdWorld(wall, ball).Step()
+	if ball.Physics.Position.X > 10 || ball.Physics.Velocity.X >= 0 {
+		t.Errorf("ball ended at %v moving %v, want it bounced back off the wall at x=10",
+			ball.Physics.Position, ball.Physics.Velocity)
+	}
+}
+
+func TestCCDBouncesHeadOn(t *testing.T) {
+	a := fastCircle(0, 1200)
+	b := fastCircle(10, -1200)
+
+	ccdWorld(a, b).Step()
+	if a.Physics.Position.X >= b.Physics.Position.X {
+		t.Errorf("circles passed through each other, a at %v and b at %v", a.Physics.Position, b.Physics.Position)
+	}
+	if a.Physics.Velocity.X >= 0 || b.Physics.Velocity.X <= 0 {
+		t.Errorf("velocities a %v and b %v, want them bounced apart", a.Physics.Velocity, b.Physics.Velocity)
+	}
+	if gap := b.Physics.Position.Sub(a.Physics.Position).Len(); gap < 4-1e-6 {
+		t.Errorf("circles %v apart, want no overlap", gap)
+	}
+}
@@ -0,0 +1,441 @@
+package primitives
+
+import "math"
+
+// Shape is the geometry a ColliderComponent collides with, local to the entity's position and rotated
+// by its angle
+type Shape interface {
+	Bounds(position Float2, angle float64) AABB
+	// Inertia is the moment of inertia about the position for the given mass
+	Inertia(mass float64) float64
+	Area() float64
+}
+
+type CircleShape struct {
+	Radius float64
+}
+
+// BoxShape is a box centred on the position
+type BoxShape struct {
+	HalfExtents Float2
+}
+
+// SegmentShape is a line segment between two points relative to the position, with no thickness
+type SegmentShape struct {
+	A, B Float2
+}
+
+// PolygonShape is a convex polygon with counter clockwise vertices
+type PolygonShape struct {
+	Vertices []Float2
+}
+
+// NewPolygonShape copies the vertices, putting them in counter clockwise order. They must form a convex
+// polygon around the entity's position
+func NewPolygonShape(vertices ...Float2) *PolygonShape {
+	vs := append([]Float2(nil), vertices...)
+	area := 0.0
+	for i, v := range vs {
+		w := vs[(i+1)%len(vs)]
+		area += v.X*w.Y - w.X*v.Y
+	}
+	if area < 0 {
+		for i, j := 0, len(vs)-1; i < j; i, j = i+1, j-1 {
+			vs[i], vs[j] = vs[j], vs[i]
+		}
+	}
+	return &PolygonShape{Vertices: vs}
+}
+
+func (s *CircleShape) Bounds(position Float2, angle float64) AABB {
+	r := Float2{X: s.Radius, Y: s.Radius}
+	return AABB{Min: position.Sub(r), Max: position.Add(r)}
+}
+
+func (s *CircleShape) Inertia(mass float64) float64 {
+	return mass * s.Radius * s.Radius / 2
+}
+
+func (s *CircleShape) Area() float64 {
+	return math.Pi * s.Radius * s.Radius
+}
+
+func (s *BoxShape) Bounds(position Float2, angle float64) AABB {
+	if angle == 0 {
+		return AABB{Min: position.Sub(s.HalfExtents), Max: position.Add(s.HalfExtents)}
+	}
+	sin, cos := math.Sincos(angle)
+	sin, cos = math.Abs(sin), math.Abs(cos)
+	h := Float2{
+		X: s.HalfExtents.X*cos + s.HalfExtents.Y*sin,
+		Y: s.HalfExtents.X*sin + s.HalfExtents.Y*cos,
+	}
+	return AABB{Min: position.Sub(h), Max: position.Add(h)}
+}
+
+func (s *BoxShape) Inertia(mass float64) float64 {
+	w, h := 2*s.HalfExtents.X, 2*s.HalfExtents.Y
+	return mass * (w*w + h*h) / 12
+}
+
+func (s *BoxShape) Area() float64 {
+	return 4 * s.HalfExtents.X * s.HalfExtents.Y
+}
+
+func (s *SegmentShape) Bounds(position Float2, angle float64) AABB {
+	return s.polygon(position, angle).bounds()
+}
+
+// Inertia is that of a thin rod about the position
+func (s *SegmentShape) Inertia(mass float64) float64 {
+	mid := s.A.Add(s.B).Scale(0.5)
+	l := s.B.Sub(s.A).Len()
+	return mass * (l*l/12 + mid.Dot(mid))
+}
+
+// Area of a segment is zero, so a density gives it no mass
+func (s *SegmentShape) Area() float64 {
+	return 0
+}
+
+func (s *PolygonShape) Bounds(position Float2, angle float64) AABB {
+	return s.polygon(position, angle).bounds()
+}
+
+// Inertia treats the polygon as a uniform solid, summing the triangles fanned from the position
+func (s *PolygonShape) Inertia(mass float64) float64 {
+	num, den := 0.0, 0.0
+	for i, a := range s.Vertices {
+		b := s.Vertices[(i+1)%len(s.Vertices)]
+		c := a.Cross(b)
+		num += c * (a.Dot(a) + a.Dot(b) + b.Dot(b))
+		den += c
+	}
+	if den == 0 {
+		return 0
+	}
+	return mass * num / (6 * den)
+}
+
+func (s *PolygonShape) Area() float64 {
+	area := 0.0
+	for i, a := range s.Vertices {
+		area += a.Cross(s.Vertices[(i+1)%len(s.Vertices)])
+	}
+	return math.Abs(area) / 2
+}
+
+// The ColliderComponent gives an entity a shape to collide with, independent of how it is rendered.
+// A nil Material uses the world's default
+type ColliderComponent struct {
+	Shape    Shape
+	Material *Material
+	// CCD sweeps a circle through each substep so it can't pass through others when moving fast.
+	// Other shapes ignore it
+	CCD bool
+}
+
+// Contact is one point of a manifold, midway through the overlap
+type Contact struct {
+	Point Float2
+	Depth float64
+}
+
+// Manifold describes how two overlapping entities touch. Normal points from A to B, and there are one or
+// two contacts
+type Manifold struct {
+	A, B     *Entity
+	Normal   Float2
+	Contacts []Contact
+}
+
+// Depth is the deepest penetration of the manifold's contacts
+func (m *Manifold) Depth() float64 {
+	depth := 0.0
+	for _, c := range m.Contacts {
+		depth = max(depth, c.Depth)
+	}
+	return depth
+}
+
+// Collide runs the narrow phase, reporting whether the two entities' colliders overlap
+func Collide(a, b *Entity) (Manifold, bool) {
+	if a.Collider == nil || b.Collider == nil {
+		return Manifold{}, false
+	}
+
+	pa, pb := a.Physics.Position, b.Physics.Position
+	angleA, angleB := a.Physics.Angle, b.Physics.Angle
+	m := Manifold{A: a, B: b}
+	var ok bool
+
+	ca, aCircle := a.Collider.Shape.(*CircleShape)
+	cb, bCircle := b.Collider.Shape.(*CircleShape)
+	switch {
+	case aCircle && bCircle:
+		m.Normal, m.Contacts, ok = collideCircles(pa, ca.Radius, pb, cb.Radius)
+	case bCircle:
+		if polyA, isPoly := polygonOf(a.Collider.Shape, pa, angleA); isPoly {
+			m.Normal, m.Contacts, ok = collidePolygonCircle(polyA, pb, cb.Radius)
+		}
+	case aCircle:
+		if polyB, isPoly := polygonOf(b.Collider.Shape, pb, angleB); isPoly {
+			m.Normal, m.Contacts, ok = collidePolygonCircle(polyB, pa, ca.Radius)
+			m.Normal = m.Normal.Scale(-1)
+		}
+	default:
+		polyA, okA := polygonOf(a.Collider.Shape, pa, angleA)
+		polyB, okB := polygonOf(b.Collider.Shape, pb, angleB)
+		if okA && okB {
+			m.Normal, m.Contacts, ok = collidePolygons(polyA, polyB)
+		}
+	}
+	return m, ok
+}
+
+func collideCircles(pa Float2, ra float64, pb Float2, rb float64) (Float2, []Contact, bool) {
+	delta := pb.Sub(pa)
+	dist := delta.Len()
+	if dist >= ra+rb {
+		return Float2{}, nil, false
+	}
+
+	normal := Float2{X: 0, Y: 1} // coincident centres, push apart vertically
+	if dist > 0 {
+		normal = delta.Scale(1 / dist)
+	}
+	depth := ra + rb - dist
+	point := pa.Add(normal.Scale(ra - depth/2))
+	return normal, []Contact{{Point: point, Depth: depth}}, true
+}
+
+// polygon is a convex shape in world space with an outward normal per edge; edge i runs from vertex i to
+// vertex i+1
+type polygon struct {
+	vertices []Float2
+	normals  []Float2
+}
+
+func polygonOf(shape Shape, position Float2, angle float64) (polygon, bool) {
+	switch s := shape.(type) {
+	case *BoxShape:
+		return s.polygon(position, angle), true
+	case *PolygonShape:
+		return s.polygon(position, angle), true
+	case *SegmentShape:
+		return s.polygon(position, angle), true
+	}
+	return polygon{}, false
+}
+
+func (s *BoxShape) polygon(position Float2, angle float64) polygon {
+	return newPolygon(BoxVertices(s.HalfExtents), position, angle)
+}
+
+// a segment is a two sided polygon: edge 0 faces one way and edge 1 the other, which SAT handles as is
+func (s *SegmentShape) polygon(position Float2, angle float64) polygon {
+	return newPolygon([]Float2{s.A, s.B}, position, angle)
+}
+
+func (s *PolygonShape) polygon(position Float2, angle float64) polygon {
+	return newPolygon(s.Vertices, position, angle)
+}
+
+func newPolygon(local []Float2, position Float2, angle float64) polygon {
+	p := polygon{
+		vertices: make([]Float2, len(local)),
+		normals:  make([]Float2, len(local)),
+	}
+	for i, v := range local {
+		if angle != 0 {
+			v = v.Rotated(angle)
+		}
+		p.vertices[i] = position.Add(v)
+	}
+	for i := range p.vertices {
+		edge := p.vertices[(i+1)%len(p.vertices)].Sub(p.vertices[i])
+		if l := edge.Len(); l > 0 {
+			p.normals[i] = Float2{X: edge.Y / l, Y: -edge.X / l}
+		}
+	}
+	return p
+}
+
+func (p polygon) bounds() AABB {
+	if len(p.vertices) == 0 {
+		return AABB{}
+	}
+	b := AABB{Min: p.vertices[0], Max: p.vertices[0]}
+	for _, v := range p.vertices[1:] {
+		b.Min = Float2{X: min(b.Min.X, v.X), Y: min(b.Min.Y, v.Y)}
+		b.Max = Float2{X: max(b.Max.X, v.X), Y: max(b.Max.Y, v.Y)}
+	}
+	return b
+}
+
+// maxSeparation finds the edge of a that b is furthest outside of. A positive separation is a
+// separating axis
+func maxSeparation(a, b polygon) (int, float64) {
+	edge, best := 0, math.Inf(-1)
+	for i, n := range a.normals {
+		sep := math.Inf(1)
+		for _, v := range b.vertices {
+			sep = min(sep, n.Dot(v.Sub(a.vertices[i])))
+		}
+		if sep > best {
+			edge, best = i, sep
+		}
+	}
+	return edge, best
+}
+
+// collidePolygons is SAT over both polygons' edge normals, then clips the incident edge against the
+// reference edge to get up to two contacts
+func collidePolygons(a, b polygon) (Float2, []Contact, bool) {
+	edgeA, sepA := maxSeparation(a, b)
+	if sepA > 0 {
+		return Float2{}, nil, false
+	}
+	edgeB, sepB := maxSeparation(b, a)
+	if sepB > 0 {
+		return Float2{}, nil, false
+	}
+
+	// prefer a as the reference unless b is clearly better, so nearly equal cases don't flicker
+	ref, inc, edge, flip := a, b, edgeA, false
+	if sepB > sepA+1e-6 {
+		ref, inc, edge, flip = b, a, edgeB, true
+	}
+
+	normal := ref.normals[edge]
+	r1 := ref.vertices[edge]
+	r2 := ref.vertices[(edge+1)%len(ref.vertices)]
+
+	// the incident edge is the one facing most against the reference normal
+	incEdge, lowest := 0, math.Inf(1)
+	for i, n := range inc.normals {
+		if d := n.Dot(normal); d < lowest {
+			incEdge, lowest = i, d
+		}
+	}
+	points := [2]Float2{inc.vertices[incEdge], inc.vertices[(incEdge+1)%len(inc.vertices)]}
+
+	// clip to the side planes of the reference edge
+	tangent := r2.Sub(r1)
+	tangent = tangent.Scale(1 / tangent.Len())
+	var ok bool
+	if points, ok = clipSegment(points, tangent.Scale(-1), -tangent.Dot(r1)); !ok {
+		return Float2{}, nil, false
+	}
+	if points, ok = clipSegment(points, tangent, tangent.Dot(r2)); !ok {
+		return Float2{}, nil, false
+	}
+
+	var contacts []Contact
+	for _, p := range points {
+		sep := normal.Dot(p.Sub(r1))
+		if sep <= 0 {
+			contacts = append(contacts, Contact{Point: p.Add(normal.Scale(-sep / 2)), Depth: -sep})
+		}
+	}
+	if len(contacts) == 0 {
+		return Float2{}, nil, false
+	}
+
+	if flip {
+		normal = normal.Scale(-1)
+	}
+	return normal, contacts, true
+}
+
+// clipSegment keeps the part of the segment where n.p <= offset
+func clipSegment(points [2]Float2, n Float2, offset float64) ([2]Float2, bool) {
+	d0 := n.Dot(points[0]) - offset
+	d1 := n.Dot(points[1]) - offset
+
+	switch {
+	case d0 <= 0 && d1 <= 0:
+		return points, true
+	case d0 > 0 && d1 > 0:
+		return points, false
+	}
+
+	cut := points[0].Add(points[1].Sub(points[0]).Scale(d0 / (d0 - d1)))
+	if d0 > 0 {
+		points[0] = cut
+	} else {
+		points[1] = cut
+	}
+	return points, true
+}
+
+// collidePolygonCircle returns the normal from the polygon to the circle
+func collidePolygonCircle(p polygon, centre Float2, radius float64) (Float2, []Contact, bool) {
+	edge, sep := 0, math.Inf(-1)
+	for i, n := range p.normals {
+		if s := n.Dot(centre.Sub(p.vertices[i])); s > sep {
+			edge, sep = i, s
+		}
+	}
+	if sep > radius {
+		return Float2{}, nil, false
+	}
+
+	normal := p.normals[edge]
+
+	// face normals alone miss the axis from the closest corner to the centre, which a circle past a
+	// corner, or past the end of a segment, is separated along
+	corner := p.vertices[0]
+	for _, v := range p.vertices[1:] {
+		if v.Sub(centre).Len() < corner.Sub(centre).Len() {
+			corner = v
+		}
+	}
+	if delta := centre.Sub(corner); delta.Len() > 0 {
+		axis := delta.Scale(1 / delta.Len())
+		reach := math.Inf(-1)
+		for _, v := range p.vertices {
+			reach = max(reach, axis.Dot(v))
+		}
+		if cornerSep := axis.Dot(centre) - reach; cornerSep > sep {
+			if cornerSep > radius {
+				return Float2{}, nil, false
+			}
+			normal, sep = axis, cornerSep
+		}
+	}
+	depth := radius - sep
+
+	point := centre.Sub(normal.Scale(radius - depth/2))
+	return normal, []Contact{{Point: point, Depth: depth}}, true
+}
+
+// Support returns the point of the entity's collider furthest along dir. Flat faces facing dir return
+// their middle, so a box resting on its side is pushed through its centre
+func Support(e *Entity, dir Float2) Float2 {
+	p := e.Physics.Position
+	if e.Collider == nil {
+		return p
+	}
+	if c, ok := e.Collider.Shape.(*CircleShape); ok {
+		return p.Add(dir.Scale(c.Radius / dir.Len()))
+	}
+
+	poly, ok := polygonOf(e.Collider.Shape, p, e.Physics.Angle)
+	if !ok || len(poly.vertices) == 0 {
+		return p
+	}
+
+	best := math.Inf(-1)
+	for _, v := range poly.vertices {
+		best = max(best, v.Dot(dir))
+	}
+	sum, count := Float2{}, 0.0
+	for _, v := range poly.vertices {
+		if v.Dot(dir) >= best-1e-6*dir.Len() {
+			sum = sum.Add(v)
+			count++
+		}
+	}
+	return sum.Scale(1 / count)
+}
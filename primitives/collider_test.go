@@ -0,0 +1,102 @@
+package primitives
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func near(a, b Float2) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func checkManifold(t *testing.T, m Manifold, normal Float2, depth float64, points ...Float2) {
+	t.Helper()
+	if !near(m.Normal, normal) {
+		t.Errorf("normal %v, want %v", m.Normal, normal)
+	}
+	if len(m.Contacts) != len(points) {
+		t.Fatalf("got %d contacts %v, want %d", len(m.Contacts), m.Contacts, len(points))
+	}
+	for _, c := range m.Contacts {
+		if math.Abs(c.Depth-depth) > 1e-9 {
+			t.Errorf("contact %v depth %v, want %v", c.Point, c.Depth, depth)
+		}
+		if !slices.ContainsFunc(points, func(p Float2) bool { return near(p, c.Point) }) {
+			t.Errorf("contact at %v, want one of %v", c.Point, points)
+		}
+	}
+}
+
+func TestCollideOverlappingBoxes(t *testing.T) {
+	a := NewBoxEntity(0, 0, 10, 10, 0, 1, 1, 1)
+	b := NewBoxEntity(8, 1, 10, 10, 0, 1, 1, 1)
+
+	m, ok := Collide(a, b)
+	if !ok {
+		t.Fatal("overlapping boxes did not collide")
+	}
+	// the faces overlap by 2 along x, and the contacts sit midway between them at the overlap's corners
+	checkManifold(t, m, Float2{X: 1, Y: 0}, 2, Float2{X: 4, Y: -4}, Float2{X: 4, Y: 5})
+
+	m, _ = Collide(b, a)
+	checkManifold(t, m, Float2{X: -1, Y: 0}, 2, Float2{X: 4, Y: -4}, Float2{X: 4, Y: 5})
+
+	b.Physics.Position.X = 10.5
+	if _, ok := Collide(a, b); ok {
+		t.Error("separated boxes collided")
+	}
+}
+
+func TestCollideRotatedPolygon(t *testing.T) {
+	floor := NewBoxEntity(0, 0, 10, 10, 0, 1, 1, 1)
+
+	// a square stood on its corner, the corner 1 below the floor's top face
+	diamond := NewPolygonEntity(0, 4+5*math.Sqrt2, BoxVertices(Float2{X: 5, Y: 5}), 0, 1, 1, 1)
+	diamond.Physics.Angle = math.Pi / 4
+
+	m, ok := Collide(floor, diamond)
+	if !ok {
+		t.Fatal("corner resting in the floor did not collide")
+	}
+	checkManifold(t, m, Float2{X: 0, Y: 1}, 1, Float2{X: 0, Y: 4.5})
+
+	// two boxes turned the same way overlap by 2 along their rotated x axis
+	angle := 0.3
+	a := NewBoxEntity(0, 0, 10, 10, 0, 1, 1, 1)
+	b := NewBoxEntity(0, 0, 10, 10, 0, 1, 1, 1)
+	a.Physics.Angle, b.Physics.Angle = angle, angle
+	b.Physics.Position = Float2{X: 8}.Rotated(angle)
+
+	m, ok = Collide(a, b)
+	if !ok {
+		t.Fatal("rotated boxes did not collide")
+	}
+	checkManifold(t, m, Float2{X: 1}.Rotated(angle), 2, Float2{X: 4, Y: -5}.Rotated(angle), Float2{X: 4, Y: 5}.Rotated(angle))
+}
+
+func TestCollideSegmentCircleEnds(t *testing.T) {
+	segment := NewSegmentObstacle(Float2{}, Float2{X: 10}, 1, 1, 1, 1)
+	circle := NewCircleEntity(50, 0, 3, 0, 1, 1, 1)
+
+	// collinear with the segment, far past its end
+	if m, ok := Collide(segment, circle); ok {
+		t.Errorf("circle 40 past the segment's end collided with depth %v", m.Depth())
+	}
+
+	// collinear and just past the end, pushed out along the segment
+	circle.Physics.Position = Float2{X: 12}
+	m, ok := Collide(segment, circle)
+	if !ok {
+		t.Fatal("circle overlapping the segment's end did not collide")
+	}
+	checkManifold(t, m, Float2{X: 1}, 1, Float2{X: 9.5})
+
+	// over the middle, pushed out across it
+	circle.Physics.Position = Float2{X: 5, Y: 2}
+	m, ok = Collide(segment, circle)
+	if !ok {
+		t.Fatal("circle over the segment did not collide")
+	}
+	checkManifold(t, m, Float2{Y: 1}, 1, Float2{X: 5, Y: -0.5})
+}
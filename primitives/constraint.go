@@ -0,0 +1,259 @@
+package primitives
+
+import (
+	"math"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/gopxl/pixel/v2/ext/imdraw"
+)
+
+// Constraint ties entities together. World calls PreStep once per substep before integration, then
+// Solve Iterations times after it
+type Constraint interface {
+	PreStep(dt float64)
+	Solve(dt float64)
+	// Anchors are the two world points the constraint links, for drawing
+	Anchors() (Float2, Float2)
+}
+
+// anchor is a point on an entity given relative to its position and rotated with it. A nil entity is the
+// world, and the local point is then in world space
+type anchor struct {
+	entity *Entity
+	local  Float2
+}
+
+// body is the anchor's entity's physics, or world, a zero mass body that never moves, for the world
+func (a anchor) body(world *PhysicsComponent) *PhysicsComponent {
+	if a.entity == nil {
+		return world
+	}
+	return a.entity.Physics
+}
+
+// arm is the anchor's offset from the body's position
+func (a anchor) arm() Float2 {
+	if a.entity == nil {
+		return Float2{}
+	}
+	return a.local.Rotated(a.entity.Physics.Angle)
+}
+
+func (a anchor) world() Float2 {
+	if a.entity == nil {
+		return a.local
+	}
+	return a.entity.Physics.Position.Add(a.arm())
+}
+
+// link is the shared solver for distance, rope and pin: it moves both anchors towards length apart, then
+// removes their relative velocity along the link. A slack link only acts when stretched
+type link struct {
+	a, b  anchor
+	slack bool
+}
+
+func (l *link) solve(length float64) {
+	pa, pb := l.a.world(), l.b.world()
+	delta := pb.Sub(pa)
+	dist := delta.Len()
+	if dist == 0 {
+		return
+	}
+	stretch := dist - length
+	if l.slack && stretch <= 0 {
+		return
+	}
+	normal := delta.Scale(1 / dist)
+
+	var world PhysicsComponent
+	a, b := l.a.body(&world), l.b.body(&world)
+	ra, rb := l.a.arm(), l.b.arm()
+	cra, crb := ra.Cross(normal), rb.Cross(normal)
+	k := a.InverseMass() + b.InverseMass() + cra*cra*a.InverseInertia() + crb*crb*b.InverseInertia()
+	if k == 0 {
+		return
+	}
+
+	// position
+	lambda := -stretch / k
+	a.Position = a.Position.Sub(normal.Scale(lambda * a.InverseMass()))
+	a.Angle -= cra * lambda * a.InverseInertia()
+	b.Position = b.Position.Add(normal.Scale(lambda * b.InverseMass()))
+	b.Angle += crb * lambda * b.InverseInertia()
+
+	// velocity
+	vn := b.VelocityAt(rb).Sub(a.VelocityAt(ra)).Dot(normal)
+	if l.slack && vn <= 0 {
+		return
+	}
+	impulse := normal.Scale(-vn / k)
+	a.ApplyImpulse(impulse.Scale(-1), ra)
+	b.ApplyImpulse(impulse, rb)
+}
+
+func (l *link) anchors() (Float2, Float2) {
+	return l.a.world(), l.b.world()
+}
+
+// Spring is a damped Hookean spring between two anchors
+type Spring struct {
+	RestLength float64
+	Stiffness  float64 // force per unit of stretch
+	Damping    float64 // force per unit of stretching speed
+
+	link
+}
+
+// NewSpring links the centres of a and b, at rest at their current distance
+func NewSpring(a, b *Entity, stiffness, damping float64) *Spring {
+	return NewSpringAt(a, ZeroFloat2, b, ZeroFloat2, stiffness, damping)
+}
+
+// NewSpringAt links points given relative to each entity, at rest at their current distance
+func NewSpringAt(a *Entity, localA Float2, b *Entity, localB Float2, stiffness, damping float64) *Spring {
+	s := &Spring{
+		Stiffness: stiffness,
+		Damping:   damping,
+		link:      link{a: anchor{a, localA}, b: anchor{b, localB}},
+	}
+	pa, pb := s.anchors()
+	s.RestLength = pb.Sub(pa).Len()
+	return s
+}
+
+func (s *Spring) PreStep(dt float64) {
+	pa, pb := s.a.world(), s.b.world()
+	delta := pb.Sub(pa)
+	dist := delta.Len()
+	if dist == 0 {
+		return
+	}
+	normal := delta.Scale(1 / dist)
+
+	var world PhysicsComponent
+	a, b := s.a.body(&world), s.b.body(&world)
+	ra, rb := s.a.arm(), s.b.arm()
+	speed := b.VelocityAt(rb).Sub(a.VelocityAt(ra)).Dot(normal)
+	force := normal.Scale(-s.Stiffness*(dist-s.RestLength) - s.Damping*speed)
+
+	b.ApplyForce(force)
+	b.ApplyTorque(rb.Cross(force))
+	a.ApplyForce(force.Scale(-1))
+	a.ApplyTorque(ra.Cross(force.Scale(-1)))
+}
+
+func (s *Spring) Solve(dt float64) {}
+
+func (s *Spring) Anchors() (Float2, Float2) { return s.anchors() }
+
+// Distance keeps two anchors exactly Length apart, like a rigid rod
+type Distance struct {
+	Length float64
+
+	link
+}
+
+// NewDistance links the centres of a and b at their current distance
+func NewDistance(a, b *Entity) *Distance {
+	return NewDistanceAt(a, ZeroFloat2, b, ZeroFloat2)
+}
+
+func NewDistanceAt(a *Entity, localA Float2, b *Entity, localB Float2) *Distance {
+	d := &Distance{link: link{a: anchor{a, localA}, b: anchor{b, localB}}}
+	pa, pb := d.anchors()
+	d.Length = pb.Sub(pa).Len()
+	return d
+}
+
+func (d *Distance) PreStep(dt float64)        {}
+func (d *Distance) Solve(dt float64)          { d.solve(d.Length) }
+func (d *Distance) Anchors() (Float2, Float2) { return d.anchors() }
+
+// Rope lets two anchors move freely up to MaxLength apart
+type Rope struct {
+	MaxLength float64
+
+	link
+}
+
+func NewRope(a, b *Entity, maxLength float64) *Rope {
+	return &Rope{
+		MaxLength: maxLength,
+		link:      link{a: anchor{a, ZeroFloat2}, b: anchor{b, ZeroFloat2}, slack: true},
+	}
+}
+
+func (r *Rope) PreStep(dt float64)        {}
+func (r *Rope) Solve(dt float64)          { r.solve(r.MaxLength) }
+func (r *Rope) Anchors() (Float2, Float2) { return r.anchors() }
+
+// Pin holds a point on an entity at a fixed point in the world
+type Pin struct {
+	link
+}
+
+// NewPin pins the entity's centre where it is now
+func NewPin(e *Entity) *Pin {
+	return NewPinAt(e, ZeroFloat2, e.Physics.Position)
+}
+
+// NewPinAt pins a point given relative to the entity to a world point
+func NewPinAt(e *Entity, local Float2, point Float2) *Pin {
+	return &Pin{link: link{a: anchor{nil, point}, b: anchor{e, local}}}
+}
+
+// Point is where the pin holds the entity; it can be moved to drag the entity along
+func (p *Pin) Point() Float2 {
+	return p.a.local
+}
+
+func (p *Pin) MoveTo(point Float2) {
+	p.a.local = point
+}
+
+func (p *Pin) PreStep(dt float64)        {}
+func (p *Pin) Solve(dt float64)          { p.solve(0) }
+func (p *Pin) Anchors() (Float2, Float2) { return p.anchors() }
+
+// LinkRender draws a constraint as a line between its anchors, or a zigzag when Coils is set. It ignores
+// the position it is drawn at
+type LinkRender struct {
+	Link      Constraint
+	Thickness float64
+	Color     Color
+	Coils     int
+}
+
+func (l *LinkRender) Draw(imd *imdraw.IMDraw, position pixel.Vec) {
+	a, b := l.Link.Anchors()
+	imd.Color = pixel.RGB(l.Color.Red, l.Color.Green, l.Color.Blue)
+	thickness := math.Max(l.Thickness, 1)
+
+	if l.Coils <= 0 {
+		imd.Push(pixel.Vec(a), pixel.Vec(b))
+		imd.Line(thickness)
+		return
+	}
+
+	delta := b.Sub(a)
+	length := delta.Len()
+	if length == 0 {
+		return
+	}
+	side := Float2{X: -delta.Y / length, Y: delta.X / length}.Scale(3 * thickness)
+
+	imd.Push(pixel.Vec(a))
+	steps := 2 * l.Coils
+	for i := 1; i < steps; i++ {
+		p := a.Add(delta.Scale(float64(i) / float64(steps)))
+		if i%2 == 0 {
+			p = p.Sub(side)
+		} else {
+			p = p.Add(side)
+		}
+		imd.Push(pixel.Vec(p))
+	}
+	imd.Push(pixel.Vec(b))
+	imd.Line(thickness)
+}
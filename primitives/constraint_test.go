@@ -0,0 +1,75 @@
+package primitives
+
+import (
+	"math"
+	"testing"
+)
+
+func constraintWorld(entities ...*Entity) *World {
+	w := NewWorld(WorldBounds{MinX: -1000, MinY: -1000, MaxX: 1000, MaxY: 1000}, Float2{X: 0, Y: -200})
+	w.Iterations = 4
+	w.Add(entities...)
+	return w
+}
+
+func TestDistanceHoldsLength(t *testing.T) {
+	a := NewCircleEntity(0, 0, 1, 0, 1, 1, 1)
+	b := NewCircleEntity(50, 0, 1, 0, 1, 1, 1)
+	b.Physics.Velocity = Float2{X: 0, Y: 300}
+
+	w := constraintWorld(a, b)
+	pin := NewPin(a)
+	rod := NewDistance(a, b)
+	w.AddConstraint(pin, nil)
+	w.AddConstraint(rod, nil)
+
+	for step := range 600 {
+		w.Step()
+		if got := b.Physics.Position.Sub(a.Physics.Position).Len(); math.Abs(got-50) > 0.01 {
+			t.Fatalf("step %d: rod is %v long, want 50", step, got)
+		}
+		if drift := a.Physics.Position.Sub(pin.Point()).Len(); drift > 0.01 {
+			t.Fatalf("step %d: pinned body drifted %v from the pin", step, drift)
+		}
+	}
+}
+
+func TestSpringSettlesAtRestLength(t *testing.T) {
+	a := NewCircleEntity(0, 0, 1, 0, 1, 1, 1)
+	b := NewCircleEntity(50, 0, 1, 0, 1, 1, 1)
+	spring := NewSpring(a, b, 200, 20)
+	b.Physics.Position.X = 80
+
+	w := constraintWorld(a, b)
+	w.Gravity = ZeroFloat2
+	w.AddConstraint(spring, nil)
+	for range 5 * 120 {
+		w.Step()
+	}
+
+	if got := b.Physics.Position.Sub(a.Physics.Position).Len(); math.Abs(got-spring.RestLength) > 0.1 {
+		t.Errorf("spring settled at %v, want its rest length %v", got, spring.RestLength)
+	}
+}
+
+func TestRopeOnlyPulls(t *testing.T) {
+	a := NewCircleEntity(0, 0, 1, 0, 1, 1, 1)
+	b := NewCircleEntity(20, 0, 1, 0, 1, 1, 1)
+
+	w := constraintWorld(a, b)
+	w.AddConstraint(NewPin(a), nil)
+	w.AddConstraint(NewRope(a, b, 50), nil)
+
+	// slack, b falls freely until the rope is taut
+	w.Step()
+	if want := -200 * w.FixedDT; math.Abs(b.Physics.Velocity.Y-want) > 1e-9 {
+		t.Errorf("slack rope changed the fall, velocity %v, want %v", b.Physics.Velocity.Y, want)
+	}
+
+	for range 600 {
+		w.Step()
+		if got := b.Physics.Position.Sub(a.Physics.Position).Len(); got > 50.5 {
+			t.Fatalf("rope stretched to %v, want at most 50", got)
+		}
+	}
+}
@@ -58,6 +58,17 @@ func (v Float2) Scale(f float64) Float2 {
 	return Float2{v.X * f, v.Y * f}
 }
 
+// Cross is the z component of the 3D cross product
+func (v Float2) Cross(o Float2) float64 {
+	return v.X*o.Y - v.Y*o.X
+}
+
+// Rotated turns the vector counter clockwise by angle radians
+func (v Float2) Rotated(angle float64) Float2 {
+	sin, cos := math.Sincos(angle)
+	return Float2{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
 // Matrix type a 2x3 matrix
 type Matrix [6]float64
 
@@ -68,12 +79,37 @@ func (m Matrix) Moved(delta Float2) Matrix {
 	return m
 }
 
-// The PhysicsComponent contains information for running the physics calculations for an object
+// ScaledXY scales everything around a given point by the scale factor in each axis
+func (m Matrix) ScaledXY(around Float2, scale Float2) Matrix {
+	m[4], m[5] = m[4]-around.X, m[5]-around.Y
+	m[0], m[2], m[4] = m[0]*scale.X, m[2]*scale.X, m[4]*scale.X
+	m[1], m[3], m[5] = m[1]*scale.Y, m[3]*scale.Y, m[5]*scale.Y
+	m[4], m[5] = m[4]+around.X, m[5]+around.Y
+	return m
+}
+
+// Project applies the matrix to a point
+func (m Matrix) Project(u Float2) Float2 {
+	return Float2{
+		X: m[0]*u.X + m[2]*u.Y + m[4],
+		Y: m[1]*u.X + m[3]*u.Y + m[5],
+	}
+}
+
+// The PhysicsComponent contains information for running the physics calculations for an object.
+// A Mass or Inertia of zero is immovable in that sense
 type PhysicsComponent struct {
 	Position     Float2
 	Velocity     Float2
 	Acceleration Float2
 	Mass         float64
+
+	Angle           float64 // radians, counter clockwise
+	AngularVelocity float64 // radians per second
+	Torque          float64
+	Inertia         float64 // moment of inertia about Position
+
+	Integrator Integrator // overrides the world's integrator when set
 }
 
 func (p *PhysicsComponent) ApplyForce(force Float2) {
@@ -82,10 +118,39 @@ func (p *PhysicsComponent) ApplyForce(force Float2) {
 	}
 }
 
+func (p *PhysicsComponent) ApplyTorque(torque float64) {
+	p.Torque += torque
+}
+
+// ApplyImpulse changes the velocity and spin at once, as a push at offset r from the position would
+func (p *PhysicsComponent) ApplyImpulse(impulse Float2, r Float2) {
+	p.Velocity = p.Velocity.Add(impulse.Scale(p.InverseMass()))
+	p.AngularVelocity += r.Cross(impulse) * p.InverseInertia()
+}
+
+func (p *PhysicsComponent) InverseMass() float64 {
+	if p.Mass <= 0 {
+		return 0
+	}
+	return 1 / p.Mass
+}
+
+func (p *PhysicsComponent) InverseInertia() float64 {
+	if p.Inertia <= 0 {
+		return 0
+	}
+	return 1 / p.Inertia
+}
+
+// VelocityAt is the velocity of the point at offset r from the position, including spin
+func (p *PhysicsComponent) VelocityAt(r Float2) Float2 {
+	return p.Velocity.Add(Float2{X: -p.AngularVelocity * r.Y, Y: p.AngularVelocity * r.X})
+}
+
+// Integrate moves the body by its accumulated forces alone with its own integrator, or SemiImplicitEuler
 func (p *PhysicsComponent) Integrate(dt float64) {
-	p.Velocity = p.Velocity.Add(p.Acceleration.Scale(dt))
-	p.Position = p.Position.Add(p.Velocity.Scale(dt))
-	p.Acceleration = ZeroFloat2
+	a := p.Acceleration
+	p.integrate(func(Float2, Float2) Float2 { return a }, nil, dt)
 }
 
 func clamp(x, min, max float64) float64 {
@@ -107,26 +172,120 @@ type RenderComponet interface {
 	Draw(imd *imdraw.IMDraw, position pixel.Vec)
 }
 
+// RotatedRender is implemented by renders that can follow the entity's orientation
+type RotatedRender interface {
+	DrawRotated(imd *imdraw.IMDraw, position pixel.Vec, angle float64)
+}
+
+// DrawRender draws r at the position, rotated by angle when it supports it
+func DrawRender(imd *imdraw.IMDraw, r RenderComponet, position pixel.Vec, angle float64) {
+	if rotated, ok := r.(RotatedRender); ok {
+		rotated.DrawRotated(imd, position, angle)
+		return
+	}
+	r.Draw(imd, position)
+}
+
 type CircleRender struct {
 	Radius    float64
 	Thickness float64
 	Color     Color
+	Marker    bool // draw a radius line so spin is visible
 }
 
 func (c *CircleRender) Draw(imd *imdraw.IMDraw, position pixel.Vec) {
+	c.DrawRotated(imd, position, 0)
+}
+
+func (c *CircleRender) DrawRotated(imd *imdraw.IMDraw, position pixel.Vec, angle float64) {
 	imd.Color = pixel.RGB(c.Color.Red, c.Color.Green, c.Color.Blue)
 	imd.Push(position)
 	imd.Circle(c.Radius, c.Thickness)
+
+	if c.Marker {
+		imd.Color = pixel.RGB(1-c.Color.Red, 1-c.Color.Green, 1-c.Color.Blue)
+		imd.Push(position, position.Add(pixel.V(c.Radius, 0).Rotated(angle)))
+		imd.Line(max(c.Thickness, 1))
+	}
+}
+
+// BoxRender draws a box centred on the position; a zero Thickness fills it
+type BoxRender struct {
+	HalfExtents Float2
+	Thickness   float64
+	Color       Color
+}
+
+func (b *BoxRender) Draw(imd *imdraw.IMDraw, position pixel.Vec) {
+	b.DrawRotated(imd, position, 0)
+}
+
+func (b *BoxRender) DrawRotated(imd *imdraw.IMDraw, position pixel.Vec, angle float64) {
+	imd.Color = pixel.RGB(b.Color.Red, b.Color.Green, b.Color.Blue)
+	for _, v := range BoxVertices(b.HalfExtents) {
+		imd.Push(position.Add(pixel.Vec(v.Rotated(angle))))
+	}
+	imd.Polygon(b.Thickness)
+}
+
+// BoxVertices are the corners of a box centred on the origin, counter clockwise from the bottom left
+func BoxVertices(half Float2) []Float2 {
+	return []Float2{
+		{X: -half.X, Y: -half.Y},
+		{X: half.X, Y: -half.Y},
+		{X: half.X, Y: half.Y},
+		{X: -half.X, Y: half.Y},
+	}
+}
+
+// PolygonRender draws a polygon with vertices relative to the position; a zero Thickness fills it
+type PolygonRender struct {
+	Vertices  []Float2
+	Thickness float64
+	Color     Color
 }
 
-// The Entity is a marrying of a PhysicsComponent and RenderComponent
+func (p *PolygonRender) Draw(imd *imdraw.IMDraw, position pixel.Vec) {
+	p.DrawRotated(imd, position, 0)
+}
+
+func (p *PolygonRender) DrawRotated(imd *imdraw.IMDraw, position pixel.Vec, angle float64) {
+	imd.Color = pixel.RGB(p.Color.Red, p.Color.Green, p.Color.Blue)
+	for _, v := range p.Vertices {
+		imd.Push(position.Add(pixel.Vec(v.Rotated(angle))))
+	}
+	imd.Polygon(p.Thickness)
+}
+
+// The Entity is a marrying of a PhysicsComponent and RenderComponent, with an optional ColliderComponent
+// for entities that take part in collisions
 type Entity struct {
-	Physics *PhysicsComponent
-	Render  RenderComponet
+	Physics  *PhysicsComponent
+	Render   RenderComponet
+	Collider *ColliderComponent
 }
 
 func (entity *Entity) Draw(imd *imdraw.IMDraw) {
-	entity.Render.Draw(imd, pixel.Vec{X: entity.Physics.Position.X, Y: entity.Physics.Position.Y})
+	DrawRender(imd, entity.Render, pixel.Vec(entity.Physics.Position), entity.Physics.Angle)
+}
+
+// Bounds is the box the entity's collider occupies, used by the broad phase. Entities without a collider
+// are a point
+func (entity *Entity) Bounds() AABB {
+	p := entity.Physics.Position
+	if entity.Collider == nil || entity.Collider.Shape == nil {
+		return AABB{Min: p, Max: p}
+	}
+	return entity.Collider.Shape.Bounds(p, entity.Physics.Angle)
+}
+
+// SetMass sets the mass and derives the moment of inertia from the collider's shape
+func (entity *Entity) SetMass(mass float64) {
+	entity.Physics.Mass = mass
+	entity.Physics.Inertia = 0
+	if entity.Collider != nil && entity.Collider.Shape != nil {
+		entity.Physics.Inertia = entity.Collider.Shape.Inertia(mass)
+	}
 }
 
 func (entity *Entity) Update(dt float64) {
@@ -136,12 +295,47 @@ func (entity *Entity) Update(dt float64) {
 func NewCircleEntity(x, y, radius, thickness float64, r, g, b float64) *Entity {
 	physics := &PhysicsComponent{
 		Position: Float2{X: x, Y: y},
-		Mass:     1.0,
 	}
 	render := &CircleRender{
 		Radius:    radius,
 		Thickness: thickness,
 		Color:     Color{Red: r, Green: g, Blue: b},
 	}
-	return &Entity{Physics: physics, Render: render}
+	collider := &ColliderComponent{Shape: &CircleShape{Radius: radius}}
+	e := &Entity{Physics: physics, Render: render, Collider: collider}
+	e.SetMass(1.0)
+	return e
+}
+
+func NewBoxEntity(x, y, width, height, thickness float64, r, g, b float64) *Entity {
+	half := Float2{X: width / 2, Y: height / 2}
+	physics := &PhysicsComponent{
+		Position: Float2{X: x, Y: y},
+	}
+	render := &BoxRender{
+		HalfExtents: half,
+		Thickness:   thickness,
+		Color:       Color{Red: r, Green: g, Blue: b},
+	}
+	collider := &ColliderComponent{Shape: &BoxShape{HalfExtents: half}}
+	e := &Entity{Physics: physics, Render: render, Collider: collider}
+	e.SetMass(1.0)
+	return e
+}
+
+// NewPolygonEntity builds a convex polygon with vertices relative to (x, y)
+func NewPolygonEntity(x, y float64, vertices []Float2, thickness float64, r, g, b float64) *Entity {
+	shape := NewPolygonShape(vertices...)
+	physics := &PhysicsComponent{
+		Position: Float2{X: x, Y: y},
+	}
+	render := &PolygonRender{
+		Vertices:  shape.Vertices,
+		Thickness: thickness,
+		Color:     Color{Red: r, Green: g, Blue: b},
+	}
+	collider := &ColliderComponent{Shape: shape}
+	e := &Entity{Physics: physics, Render: render, Collider: collider}
+	e.SetMass(1.0)
+	return e
 }
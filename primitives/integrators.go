@@ -0,0 +1,155 @@
+package primitives
+
+import "math"
+
+// AccelerationFunc is the acceleration a body would have at a trial position and velocity
+type AccelerationFunc func(position, velocity Float2) Float2
+
+// Integrator advances a body's position and velocity by dt. Higher order integrators sample accel at
+// several trial states within the step
+type Integrator interface {
+	Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64)
+}
+
+// ForceField is an acceleration that depends on where an entity is and how it moves, such as a spring to
+// a fixed point or the pull of a planet
+type ForceField func(e *Entity, position, velocity Float2) Float2
+
+// Dynamics is how entities move between contacts: what accelerates them and how that is integrated
+type Dynamics struct {
+	Gravity    Float2
+	Fields     []ForceField
+	Integrator Integrator // for entities without their own
+}
+
+// acceleration combines the entity's accumulated forces, gravity and every field
+func (d *Dynamics) acceleration(e *Entity) AccelerationFunc {
+	constant := e.Physics.Acceleration.Add(d.Gravity)
+	if len(d.Fields) == 0 {
+		return func(Float2, Float2) Float2 { return constant }
+	}
+	return func(position, velocity Float2) Float2 {
+		a := constant
+		for _, field := range d.Fields {
+			a = a.Add(field(e, position, velocity))
+		}
+		return a
+	}
+}
+
+// integrate moves the entity with its own integrator, or the default, and spins it by its torque
+func (d *Dynamics) integrate(e *Entity, dt float64) {
+	e.Physics.integrate(d.acceleration(e), d.Integrator, dt)
+}
+
+// drive moves a kinematic entity by its velocity and its own forces only, since gravity and fields don't
+// act on bodies without mass
+func (d *Dynamics) drive(e *Entity, dt float64) {
+	a := e.Physics.Acceleration
+	e.Physics.integrate(func(Float2, Float2) Float2 { return a }, d.Integrator, dt)
+}
+
+// integrate moves the body with its own integrator, then fallback, then SemiImplicitEuler, and spins it
+// by its torque, clearing both accumulators
+func (p *PhysicsComponent) integrate(accel AccelerationFunc, fallback Integrator, dt float64) {
+	integrator := p.Integrator
+	if integrator == nil {
+		integrator = fallback
+	}
+	if integrator == nil {
+		integrator = SemiImplicitEuler{}
+	}
+
+	integrator.Integrate(p, accel, dt)
+	p.Acceleration = ZeroFloat2
+
+	p.AngularVelocity += p.Torque * p.InverseInertia() * dt
+	p.Angle += p.AngularVelocity * dt
+	p.Torque = 0
+}
+
+// ExplicitEuler moves with the old velocity, then updates it. It gains energy and is only here for
+// comparison
+type ExplicitEuler struct{}
+
+func (ExplicitEuler) Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64) {
+	a := accel(p.Position, p.Velocity)
+	p.Position = p.Position.Add(p.Velocity.Scale(dt))
+	p.Velocity = p.Velocity.Add(a.Scale(dt))
+}
+
+// SemiImplicitEuler updates the velocity first and moves with the new one. It is symplectic, cheap, and
+// the default
+type SemiImplicitEuler struct{}
+
+func (SemiImplicitEuler) Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64) {
+	p.Velocity = p.Velocity.Add(accel(p.Position, p.Velocity).Scale(dt))
+	p.Position = p.Position.Add(p.Velocity.Scale(dt))
+}
+
+// VelocityVerlet moves with the current acceleration, then averages it with the acceleration at the new
+// position to update the velocity
+type VelocityVerlet struct{}
+
+func (VelocityVerlet) Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64) {
+	a0 := accel(p.Position, p.Velocity)
+	p.Position = p.Position.Add(p.Velocity.Scale(dt)).Add(a0.Scale(dt * dt / 2))
+	a1 := accel(p.Position, p.Velocity.Add(a0.Scale(dt)))
+	p.Velocity = p.Velocity.Add(a0.Add(a1).Scale(dt / 2))
+}
+
+// PositionVerlet drifts half a step, kicks the velocity with the acceleration there, then drifts the
+// other half
+type PositionVerlet struct{}
+
+func (PositionVerlet) Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64) {
+	mid := p.Position.Add(p.Velocity.Scale(dt / 2))
+	p.Velocity = p.Velocity.Add(accel(mid, p.Velocity).Scale(dt))
+	p.Position = mid.Add(p.Velocity.Scale(dt / 2))
+}
+
+// RK4 is the classic fourth order Runge-Kutta method. It is the most accurate over a step but not
+// symplectic, so energy slowly leaks over long runs
+type RK4 struct{}
+
+func (RK4) Integrate(p *PhysicsComponent, accel AccelerationFunc, dt float64) {
+	x, v := p.Position, p.Velocity
+
+	k1x, k1v := v, accel(x, v)
+	k2x, k2v := v.Add(k1v.Scale(dt/2)), accel(x.Add(k1x.Scale(dt/2)), v.Add(k1v.Scale(dt/2)))
+	k3x, k3v := v.Add(k2v.Scale(dt/2)), accel(x.Add(k2x.Scale(dt/2)), v.Add(k2v.Scale(dt/2)))
+	k4x, k4v := v.Add(k3v.Scale(dt)), accel(x.Add(k3x.Scale(dt)), v.Add(k3v.Scale(dt)))
+
+	p.Position = x.Add(k1x.Add(k2x.Scale(2)).Add(k3x.Scale(2)).Add(k4x).Scale(dt / 6))
+	p.Velocity = v.Add(k1v.Add(k2v.Scale(2)).Add(k3v.Scale(2)).Add(k4v).Scale(dt / 6))
+}
+
+// SpringField pulls every entity towards anchor with stiffness per unit mass, a harmonic oscillator
+func SpringField(anchor Float2, stiffness float64) ForceField {
+	return func(e *Entity, position, velocity Float2) Float2 {
+		return anchor.Sub(position).Scale(stiffness)
+	}
+}
+
+// PointGravity pulls every entity towards centre with strength gm / r², softened within softening of
+// the centre so close passes stay finite
+func PointGravity(centre Float2, gm, softening float64) ForceField {
+	return func(e *Entity, position, velocity Float2) Float2 {
+		delta := centre.Sub(position)
+		r2 := delta.Dot(delta) + softening*softening
+		return delta.Scale(gm / (r2 * math.Sqrt(r2)))
+	}
+}
+
+// CircularField pulls entities within radius towards centre, strongest at the centre and fading to nothing
+// at the edge. A negative strength pushes them away
+func CircularField(centre Float2, radius, strength float64) ForceField {
+	return func(e *Entity, position, velocity Float2) Float2 {
+		delta := centre.Sub(position)
+		dist := math.Sqrt(delta.Dot(delta))
+		if dist > radius || dist < 0.0001 {
+			return ZeroFloat2
+		}
+		return delta.Scale(strength * (1 - dist/radius) / dist)
+	}
+}
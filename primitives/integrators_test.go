@@ -0,0 +1,127 @@
+package primitives
+
+import (
+	"math"
+	"testing"
+)
+
+// energyScenario is a body moving under one field, with its total energy per unit mass
+type energyScenario struct {
+	name   string
+	dt     float64
+	steps  int
+	setup  func(w *World) *Entity
+	energy func(p *PhysicsComponent) float64
+}
+
+// harmonic is a unit mass on a spring with unit angular frequency, starting at rest one unit out
+func harmonic(periods float64) energyScenario {
+	const k, dt = 1.0, 0.05
+	return energyScenario{
+		name:  "harmonic",
+		dt:    dt,
+		steps: int(periods * 2 * math.Pi / dt),
+		setup: func(w *World) *Entity {
+			w.Fields = append(w.Fields, SpringField(ZeroFloat2, k))
+			e := NewCircleEntity(1, 0, 0.01, 0, 1, 1, 1)
+			w.Add(e)
+			return e
+		},
+		energy: func(p *PhysicsComponent) float64 {
+			return 0.5*p.Velocity.Dot(p.Velocity) + 0.5*k*p.Position.Dot(p.Position)
+		},
+	}
+}
+
+// orbit is a light body on an eccentric Kepler orbit around a fixed unit mass
+func orbit(periods float64) energyScenario {
+	const gm, dt = 1.0, 0.01
+	r0, v0 := 1.0, 1.2
+
+	// semi-major axis from the vis-viva equation, for the period
+	a := 1 / (2/r0 - v0*v0/gm)
+	period := 2 * math.Pi * math.Sqrt(a*a*a/gm)
+
+	return energyScenario{
+		name:  "orbit",
+		dt:    dt,
+		steps: int(periods * period / dt),
+		setup: func(w *World) *Entity {
+			w.Fields = append(w.Fields, PointGravity(ZeroFloat2, gm, 0))
+			e := NewCircleEntity(r0, 0, 0.01, 0, 1, 1, 1)
+			e.Physics.Velocity = Float2{X: 0, Y: v0}
+			w.Add(e)
+			return e
+		},
+		energy: func(p *PhysicsComponent) float64 {
+			return 0.5*p.Velocity.Dot(p.Velocity) - gm/p.Position.Len()
+		},
+	}
+}
+
+// run steps the scenario through a World, returning the largest relative energy error and the error
+// halfway and at the end
+func (sc energyScenario) run(integrator Integrator) (maxDrift, half, final float64) {
+	w := NewWorld(WorldBounds{}, ZeroFloat2)
+	w.FixedDT = sc.dt
+	w.Integrator = integrator
+	body := sc.setup(w)
+
+	e0 := sc.energy(body.Physics)
+	for i := range sc.steps {
+		w.Step()
+		final = (sc.energy(body.Physics) - e0) / math.Abs(e0)
+		maxDrift = max(maxDrift, math.Abs(final))
+		if i == sc.steps/2 {
+			half = final
+		}
+	}
+	return maxDrift, half, final
+}
+
+func TestIntegratorEnergyDrift(t *testing.T) {
+	const periods = 50
+
+	tests := []struct {
+		name       string
+		integrator Integrator
+		maxDrift   float64 // largest relative energy error allowed; 0 expects the energy to grow
+	}{
+		{"explicit euler", ExplicitEuler{}, 0},
+		{"semi-implicit euler", SemiImplicitEuler{}, 0.05},
+		{"velocity verlet", VelocityVerlet{}, 1e-3},
+		{"position verlet", PositionVerlet{}, 1e-3},
+		{"rk4", RK4{}, 1e-3},
+	}
+
+	for _, sc := range []energyScenario{harmonic(periods), orbit(periods)} {
+		for _, tt := range tests {
+			t.Run(sc.name+"/"+tt.name, func(t *testing.T) {
+				maxDrift, half, final := sc.run(tt.integrator)
+				if tt.maxDrift == 0 {
+					if !(final > half && half > 0) {
+						t.Errorf("energy drift %.3e halfway and %.3e at the end, want it growing", half, final)
+					}
+					return
+				}
+				if maxDrift > tt.maxDrift {
+					t.Errorf("max energy drift %.3e, want below %.0e", maxDrift, tt.maxDrift)
+				}
+			})
+		}
+	}
+}
+
+func TestIntegrateUsesTheBodysIntegrator(t *testing.T) {
+	p := &PhysicsComponent{Velocity: Float2{X: 1}, Integrator: ExplicitEuler{}}
+	p.Acceleration = Float2{X: 2}
+	p.Integrate(0.5)
+
+	// explicit Euler moves with the old velocity, semi-implicit would have moved by 1
+	if p.Position.X != 0.5 || p.Velocity.X != 2 {
+		t.Errorf("position %v velocity %v, want explicit Euler's 0.5 and 2", p.Position, p.Velocity)
+	}
+	if p.Acceleration != ZeroFloat2 {
+		t.Errorf("acceleration %v left after Integrate, want it cleared", p.Acceleration)
+	}
+}
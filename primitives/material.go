@@ -0,0 +1,94 @@
+package primitives
+
+import "math"
+
+// Material describes how an entity's surface responds to contacts, and how heavy it is for its size
+type Material struct {
+	Restitution     float64 // bounciness, 0 to 1
+	StaticFriction  float64 // Coulomb coefficient below which contacts stick
+	DynamicFriction float64 // Coulomb coefficient while sliding
+	Density         float64 // mass per unit area; 0 leaves the mass alone
+}
+
+var (
+	MaterialDefault = Material{Restitution: 0.6, StaticFriction: 0.3, DynamicFriction: 0.3}
+	MaterialRubber  = Material{Restitution: 0.85, StaticFriction: 0.9, DynamicFriction: 0.8, Density: 0.012}
+	MaterialWood    = Material{Restitution: 0.3, StaticFriction: 0.5, DynamicFriction: 0.4, Density: 0.006}
+	MaterialSteel   = Material{Restitution: 0.5, StaticFriction: 0.7, DynamicFriction: 0.55, Density: 0.08}
+	MaterialIce     = Material{Restitution: 0.1, StaticFriction: 0.05, DynamicFriction: 0.02, Density: 0.009}
+)
+
+// CombineRule mixes a property of the two materials in a contact
+type CombineRule int
+
+const (
+	CombineAverage CombineRule = iota
+	CombineMin
+	CombineMax
+	CombineMultiply
+)
+
+func (r CombineRule) Combine(a, b float64) float64 {
+	switch r {
+	case CombineMin:
+		return math.Min(a, b)
+	case CombineMax:
+		return math.Max(a, b)
+	case CombineMultiply:
+		return a * b
+	}
+	return (a + b) / 2
+}
+
+// ContactRules decide the restitution and friction of a contact from the materials on either side
+type ContactRules struct {
+	Default     Material // for entities without a material, and for the world bounds
+	Restitution CombineRule
+	Friction    CombineRule
+}
+
+func DefaultContactRules() ContactRules {
+	return ContactRules{
+		Default:     MaterialDefault,
+		Restitution: CombineMax,
+		Friction:    CombineAverage,
+	}
+}
+
+// contactMaterial is the combined response of one contact
+type contactMaterial struct {
+	restitution     float64
+	staticFriction  float64
+	dynamicFriction float64
+}
+
+func (r *ContactRules) material(e *Entity) *Material {
+	if e != nil && e.Collider != nil && e.Collider.Material != nil {
+		return e.Collider.Material
+	}
+	return &r.Default
+}
+
+// combine mixes the materials of a and b; a nil entity is the world bounds
+func (r *ContactRules) combine(a, b *Entity) contactMaterial {
+	ma, mb := r.material(a), r.material(b)
+	return contactMaterial{
+		restitution:     r.Restitution.Combine(ma.Restitution, mb.Restitution),
+		staticFriction:  r.Friction.Combine(ma.StaticFriction, mb.StaticFriction),
+		dynamicFriction: r.Friction.Combine(ma.DynamicFriction, mb.DynamicFriction),
+	}
+}
+
+// SetMaterial gives the entity's collider a material, and when it has a density, sets the mass and
+// inertia from the collider's area. Shapes without area, like segments, keep their mass
+func (entity *Entity) SetMaterial(m Material) {
+	if entity.Collider == nil {
+		return
+	}
+	entity.Collider.Material = &m
+	if m.Density > 0 && entity.Collider.Shape != nil && !entity.IsStatic() {
+		if area := entity.Collider.Shape.Area(); area > 0 {
+			entity.SetMass(m.Density * area)
+		}
+	}
+}
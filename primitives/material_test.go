@@ -0,0 +1,22 @@
+package primitives
+
+import "testing"
+
+func TestSetMaterialKeepsMassWithoutArea(t *testing.T) {
+	rod := &Entity{
+		Physics:  &PhysicsComponent{},
+		Collider: &ColliderComponent{Shape: &SegmentShape{A: Float2{X: -10}, B: Float2{X: 10}}},
+	}
+	rod.SetMass(2)
+	rod.SetMaterial(MaterialSteel)
+
+	if rod.IsStatic() || rod.Physics.Mass != 2 {
+		t.Errorf("segment mass %v after SetMaterial, want 2", rod.Physics.Mass)
+	}
+
+	ball := NewCircleEntity(0, 0, 10, 0, 1, 1, 1)
+	ball.SetMaterial(MaterialSteel)
+	if want := MaterialSteel.Density * ball.Collider.Shape.Area(); ball.Physics.Mass != want {
+		t.Errorf("circle mass %v after SetMaterial, want %v", ball.Physics.Mass, want)
+	}
+}
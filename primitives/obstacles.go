@@ -0,0 +1,86 @@
+package primitives
+
+import (
+	"github.com/gopxl/pixel/v2"
+	"github.com/gopxl/pixel/v2/ext/imdraw"
+)
+
+// Static obstacles are ordinary entities with no mass or inertia: nothing can push them, gravity
+// doesn't pull them and the world bounds don't hold them. Given a velocity they still move, which makes
+// them kinematic, like a pinball flipper
+
+// SegmentRender draws a line between two points relative to the position
+type SegmentRender struct {
+	A, B      Float2
+	Thickness float64
+	Color     Color
+}
+
+func (s *SegmentRender) Draw(imd *imdraw.IMDraw, position pixel.Vec) {
+	s.DrawRotated(imd, position, 0)
+}
+
+func (s *SegmentRender) DrawRotated(imd *imdraw.IMDraw, position pixel.Vec, angle float64) {
+	imd.Color = pixel.RGB(s.Color.Red, s.Color.Green, s.Color.Blue)
+	imd.Push(position.Add(pixel.Vec(s.A.Rotated(angle))), position.Add(pixel.Vec(s.B.Rotated(angle))))
+	imd.Line(max(s.Thickness, 1))
+}
+
+// MakeStatic removes the entity's mass and inertia so it is immovable
+func (entity *Entity) MakeStatic() {
+	entity.Physics.Mass = 0
+	entity.Physics.Inertia = 0
+	entity.Physics.Velocity = ZeroFloat2
+	entity.Physics.AngularVelocity = 0
+}
+
+// IsStatic reports whether nothing can move the entity
+func (entity *Entity) IsStatic() bool {
+	return entity.Physics.InverseMass() == 0 && entity.Physics.InverseInertia() == 0
+}
+
+// NewSegmentObstacle is a static line from a to b in world space
+func NewSegmentObstacle(a, b Float2, thickness float64, r, g, bl float64) *Entity {
+	mid := a.Add(b).Scale(0.5)
+	la, lb := a.Sub(mid), b.Sub(mid)
+	return &Entity{
+		Physics:  &PhysicsComponent{Position: mid},
+		Render:   &SegmentRender{A: la, B: lb, Thickness: thickness, Color: Color{Red: r, Green: g, Blue: bl}},
+		Collider: &ColliderComponent{Shape: &SegmentShape{A: la, B: lb}},
+	}
+}
+
+// NewPolylineObstacle is a static segment between each pair of consecutive points
+func NewPolylineObstacle(points []Float2, thickness float64, r, g, b float64) []*Entity {
+	var segments []*Entity
+	for i := 1; i < len(points); i++ {
+		segments = append(segments, NewSegmentObstacle(points[i-1], points[i], thickness, r, g, b))
+	}
+	return segments
+}
+
+// NewBoundsObstacle walls in the bounds with four static segments, for worlds that need the walls to
+// be ordinary geometry
+func NewBoundsObstacle(bounds WorldBounds, thickness float64, r, g, b float64) []*Entity {
+	return NewPolylineObstacle([]Float2{
+		{X: bounds.MinX, Y: bounds.MinY},
+		{X: bounds.MaxX, Y: bounds.MinY},
+		{X: bounds.MaxX, Y: bounds.MaxY},
+		{X: bounds.MinX, Y: bounds.MaxY},
+		{X: bounds.MinX, Y: bounds.MinY},
+	}, thickness, r, g, b)
+}
+
+// NewBoxObstacle is a static box centred on (x, y) and rotated by angle radians
+func NewBoxObstacle(x, y, width, height, angle float64, r, g, b float64) *Entity {
+	e := NewBoxEntity(x, y, width, height, 0, r, g, b)
+	e.Physics.Angle = angle
+	e.MakeStatic()
+	return e
+}
+
+func NewCircleObstacle(x, y, radius float64, r, g, b float64) *Entity {
+	e := NewCircleEntity(x, y, radius, 0, r, g, b)
+	e.MakeStatic()
+	return e
+}
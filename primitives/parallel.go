@@ -0,0 +1,30 @@
+package primitives
+
+import "sync"
+
+// minChunk keeps goroutine overhead from dominating small batches
+const minChunk = 256
+
+// parallelFor splits [0, n) into contiguous chunks and runs fn on them across up to workers goroutines,
+// returning once all are done. Chunks are fixed by n and workers, never by scheduling
+func parallelFor(n, workers int, fn func(lo, hi int)) {
+	workers = min(workers, (n+minChunk-1)/minChunk)
+	if workers <= 1 {
+		if n > 0 {
+			fn(0, n)
+		}
+		return
+	}
+
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,93 @@
+package primitives
+
+import (
+	"math"
+	"math/rand"
+)
+
+// CreateChaoticCircles scatters count circles inside the window with random colours and velocities.
+// Passing the same seeded rng reproduces the same layout
+func CreateChaoticCircles(
+	rng *rand.Rand,
+	count int,
+	radius float64,
+	windowWidth float64,
+	windowHeight float64,
+	speedMin float64,
+	speedMax float64,
+) []*Entity {
+	circles := make([]*Entity, 0, count)
+
+	for range count {
+		x := rng.Float64()*(windowWidth-2*radius) + radius
+		y := rng.Float64()*(windowHeight-2*radius) + radius
+
+		e := NewCircleEntity(x, y, radius, 0,
+			rng.Float64(), // red
+			rng.Float64(), // green
+			rng.Float64(), // blue
+		)
+
+		speed := speedMin + rng.Float64()*(speedMax-speedMin)
+		angle := rng.Float64() * 2 * math.Pi
+		e.Physics.Velocity = Float2{X: math.Cos(angle) * speed, Y: math.Sin(angle) * speed}
+
+		circles = append(circles, e)
+	}
+
+	return circles
+}
+
+// UpdateEntities advances every entity by dt: integration under the dynamics, world bounds, then object
+// collisions, with rules mixing the materials of each contact. CCD circles are swept back to their first
+// impact before the discrete pass, and contacts are solved iterations times, reusing the step's grid,
+// which stacks need to settle. Per entity work and collisions are split across workers; the result is
+// the same for any worker count
+func UpdateEntities(
+	entities []*Entity,
+	grid *SpatialGrid,
+	dynamics Dynamics,
+	bounds WorldBounds,
+	rules ContactRules,
+	dt float64,
+	iterations int,
+	workers int,
+) {
+	sweeps := startSweeps(entities, nil)
+
+	parallelFor(len(entities), workers, func(lo, hi int) {
+		for _, e := range entities[lo:hi] {
+			if e.IsStatic() {
+				dynamics.drive(e, dt) // kinematic obstacles still follow their velocity
+				continue
+			}
+			dynamics.integrate(e, dt)
+			e.HandleBoundaryCollisions(bounds, &rules)
+		}
+	})
+
+	// inserting serially keeps the cell order, and so the collision order, fixed
+	grid.Clear()
+	for _, e := range entities {
+		grid.Insert(e)
+	}
+
+	if len(sweeps) > 0 && resolveSweeps(sweeps, grid, &rules) {
+		grid.Clear()
+		for _, e := range entities {
+			grid.Insert(e)
+		}
+	}
+
+	HandleObjectCollisions(grid, &rules, dt, workers)
+	for range iterations - 1 {
+		parallelFor(len(entities), workers, func(lo, hi int) {
+			for _, e := range entities[lo:hi] {
+				if !e.IsStatic() {
+					e.HandleBoundaryCollisions(bounds, &rules)
+				}
+			}
+		})
+		HandleObjectCollisions(grid, &rules, dt, workers)
+	}
+}
@@ -13,59 +13,173 @@ type WorldBounds struct {
 	MaxY float64
 }
 
+// Empty bounds have no area; a world with empty bounds has no walls
+func (b WorldBounds) Empty() bool {
+	return b.MaxX <= b.MinX || b.MaxY <= b.MinY
+}
+
+// AABB is an axis aligned bounding box
+type AABB struct {
+	Min Float2
+	Max Float2
+}
+
+func (a AABB) Overlaps(b AABB) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X &&
+		a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
+
+// SpatialGrid is the broad phase: entities are inserted into every cell their bounds overlap, so
+// neighbours across cell boundaries and entities larger than a cell share at least one cell.
+// Cells are visited in insertion order, which keeps pair order deterministic
 type SpatialGrid struct {
 	CellSize float64
-	Buckets  map[[2]int][]*Entity
+
+	cells map[[2]int][]gridEntry
+	order [][2]int // non-empty cells in the order they were first filled
+	wide  int      // entities spanning more than two cells on an axis
+}
+
+// gridEntry snapshots the bounds at insert time so a pair is judged the same way in every cell
+type gridEntry struct {
+	entity *Entity
+	bounds AABB
+	wide   bool // spans more than two cells on an axis, see HandleObjectCollisions
+}
+
+func NewSpatialGrid(cellSize float64) *SpatialGrid {
+	return &SpatialGrid{
+		CellSize: cellSize,
+		cells:    map[[2]int][]gridEntry{},
+	}
 }
 
 func (grid *SpatialGrid) Clear() {
-	for k := range grid.Buckets {
-		delete(grid.Buckets, k)
+	// keep the cell slices around to avoid reallocating them every step
+	for _, key := range grid.order {
+		grid.cells[key] = grid.cells[key][:0]
+	}
+	grid.order = grid.order[:0]
+	grid.wide = 0
+}
+
+func (grid *SpatialGrid) cell(p Float2) [2]int {
+	return [2]int{
+		int(math.Floor(p.X / grid.CellSize)),
+		int(math.Floor(p.Y / grid.CellSize)),
 	}
 }
 
 func (grid *SpatialGrid) Insert(entity *Entity) {
-	x := int(math.Floor(entity.Physics.Position.X / grid.CellSize))
-	y := int(math.Floor(entity.Physics.Position.Y / grid.CellSize))
+	grid.insert(entity, entity.Bounds())
+}
+
+// insert adds the entity under the given bounds rather than its own
+func (grid *SpatialGrid) insert(entity *Entity, bounds AABB) {
+	if grid.cells == nil {
+		grid.cells = map[[2]int][]gridEntry{}
+	}
 
-	key := [2]int{x, y}
-	grid.Buckets[key] = append(grid.Buckets[key], entity)
+	lo, hi := grid.cell(bounds.Min), grid.cell(bounds.Max)
+	wide := hi[0]-lo[0] > 1 || hi[1]-lo[1] > 1
+	if wide {
+		grid.wide++
+	}
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			key := [2]int{x, y}
+			cell := grid.cells[key]
+			if len(cell) == 0 {
+				grid.order = append(grid.order, key)
+			}
+			grid.cells[key] = append(cell, gridEntry{entity: entity, bounds: bounds, wide: wide})
+		}
+	}
 }
 
-func (entity *Entity) HandleBoundaryCollisions(bounds WorldBounds, restitution float64, friction float64) {
-	// only handling circles for now
-	circle, ok := entity.Render.(*CircleRender)
-	if !ok {
-		return
+// ForEachPair calls fn once for every pair of entities whose bounds overlap. A pair sharing several cells
+// is only reported by the cell holding the minimum corner of the overlap of their bounds
+func (grid *SpatialGrid) ForEachPair(fn func(a, b *Entity)) {
+	for _, key := range grid.order {
+		grid.cellPairs(key, pairAll, fn)
 	}
+}
+
+type pairFilter int
 
-	position := &entity.Physics.Position
-	velocity := &entity.Physics.Velocity
-	radius := circle.Radius
+const (
+	pairAll    pairFilter = iota
+	pairNarrow            // both entities span at most two cells per axis
+	pairWide              // at least one entity spans more
+)
 
-	// Bottom Floor - friction applied to simulate gravity holding the entity to the ground while moving
-	if position.Y-radius < bounds.MinY {
-		position.Y = bounds.MinY + radius
-		velocity.Y = -velocity.Y * restitution
-		velocity.X *= friction
+// cellPairs reports the pairs owned by one cell
+func (grid *SpatialGrid) cellPairs(key [2]int, filter pairFilter, fn func(a, b *Entity)) {
+	cell := grid.cells[key]
+	for i := 0; i < len(cell); i++ {
+		for j := i + 1; j < len(cell); j++ {
+			if filter != pairAll && (cell[i].wide || cell[j].wide) != (filter == pairWide) {
+				continue
+			}
+			a, b := cell[i].bounds, cell[j].bounds
+			if !a.Overlaps(b) {
+				continue
+			}
+			corner := Float2{X: max(a.Min.X, b.Min.X), Y: max(a.Min.Y, b.Min.Y)}
+			if grid.cell(corner) != key {
+				continue
+			}
+			fn(cell[i].entity, cell[j].entity)
+		}
 	}
+}
 
-	// Top Wall
-	if position.Y+radius > bounds.MaxY {
-		position.Y = bounds.MaxY - radius
-		velocity.Y = -velocity.Y * restitution
+// Query calls fn once for every entity whose bounds overlap the region
+func (grid *SpatialGrid) Query(region AABB, fn func(e *Entity)) {
+	lo, hi := grid.cell(region.Min), grid.cell(region.Max)
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			key := [2]int{x, y}
+			for _, entry := range grid.cells[key] {
+				if !entry.bounds.Overlaps(region) {
+					continue
+				}
+				corner := Float2{X: max(entry.bounds.Min.X, region.Min.X), Y: max(entry.bounds.Min.Y, region.Min.Y)}
+				if grid.cell(corner) != key {
+					continue
+				}
+				fn(entry.entity)
+			}
+		}
 	}
+}
 
-	// Left Wall
-	if position.X-radius < bounds.MinX {
-		position.X = bounds.MinX + radius
-		velocity.X = -velocity.X * restitution
+// HandleBoundaryCollisions keeps the entity inside the world. Each wall is an immovable contact at the
+// entity's deepest point, so off centre hits spin it and friction makes it roll
+func (entity *Entity) HandleBoundaryCollisions(bounds WorldBounds, rules *ContactRules) {
+	if entity.Collider == nil || bounds.Empty() {
+		return
 	}
 
-	// Right Wall
-	if position.X+radius > bounds.MaxX {
-		position.X = bounds.MaxX - radius
-		velocity.X = -velocity.X * restitution
+	walls := [...]struct {
+		normal Float2 // into the world
+		depth  func(box AABB) float64
+	}{
+		{Float2{X: 0, Y: 1}, func(box AABB) float64 { return bounds.MinY - box.Min.Y }},  // floor
+		{Float2{X: 0, Y: -1}, func(box AABB) float64 { return box.Max.Y - bounds.MaxY }}, // top
+		{Float2{X: 1, Y: 0}, func(box AABB) float64 { return bounds.MinX - box.Min.X }},  // left
+		{Float2{X: -1, Y: 0}, func(box AABB) float64 { return box.Max.X - bounds.MaxX }}, // right
+	}
+
+	var wall PhysicsComponent // zero mass, never moves
+	for _, w := range walls {
+		depth := w.depth(entity.Bounds())
+		if depth <= 0 {
+			continue
+		}
+		entity.Physics.Position = entity.Physics.Position.Add(w.normal.Scale(depth))
+		point := Support(entity, w.normal.Scale(-1))
+		applyContactImpulse(&wall, entity.Physics, w.normal, []Float2{point}, rules.combine(nil, entity))
 	}
 }
 
@@ -105,50 +219,235 @@ func ApplyCircularForce(attrative bool, entities []*Entity, point pixel.Vec, rad
 	}
 }
 
-func (entity *Entity) HandleObjectCollisions(grid *SpatialGrid) {
-	for _, cell := range grid.Buckets {
-		for i := 0; i < len(cell); i++ {
-			for j := i + 1; j < len(cell); j++ {
-				resolveCollisions(cell[i], cell[j])
-			}
+// HandleObjectCollisions resolves every overlapping pair found by the grid once, spread over workers.
+//
+// Cells are coloured in a 2x2 checkerboard and each colour is a separate phase. An entity spanning at
+// most two cells per axis can't sit in two cells of the same colour, so within a phase every cell touches
+// its own entities and the cells can run in any order on any worker with the same result. Pairs with a
+// wider entity are resolved serially afterwards. The outcome depends only on the grid, not on workers.
+// dt is the step the entities were just integrated over
+func HandleObjectCollisions(grid *SpatialGrid, rules *ContactRules, dt float64, workers int) {
+	resolve := func(a, b *Entity) {
+		if a.IsStatic() && b.IsStatic() {
+			return
+		}
+		if m, ok := Collide(a, b); ok {
+			resolveManifold(&m, rules.combine(a, b), dt)
 		}
 	}
+
+	var phases [4][][2]int
+	for _, key := range grid.order {
+		colour := key[0]&1 | (key[1]&1)<<1
+		phases[colour] = append(phases[colour], key)
+	}
+
+	for _, keys := range phases {
+		parallelFor(len(keys), workers, func(lo, hi int) {
+			for _, key := range keys[lo:hi] {
+				grid.cellPairs(key, pairNarrow, resolve)
+			}
+		})
+	}
+
+	if grid.wide == 0 {
+		return
+	}
+	for _, key := range grid.order {
+		grid.cellPairs(key, pairWide, resolve)
+	}
 }
 
-func resolveCollisions(a, b *Entity) {
-	// only handling circles for now
-	ca, ok1 := a.Render.(*CircleRender)
-	cb, ok2 := b.Render.(*CircleRender)
-	if !ok1 || !ok2 {
+// restingSpeed is the approach speed below which contacts don't bounce, so resting stacks settle
+const restingSpeed = 10
+
+// resolveManifold separates the pair by their inverse masses, then applies a normal and friction impulse
+// across the contacts. Contacts that stick also take back the sliding they did while being integrated
+// over dt, otherwise a body held by static friction on a slope creeps down it a little every step
+func resolveManifold(m *Manifold, mat contactMaterial, dt float64) {
+	a, b := m.A.Physics, m.B.Physics
+	invA, invB := a.InverseMass(), b.InverseMass()
+	if invA+invB == 0 {
 		return
 	}
 
-	positionA := a.Physics.Position
-	positionB := b.Physics.Position
+	// Push them appart
+	overlap := m.Depth()
+	a.Position = a.Position.Add(m.Normal.Scale(-overlap * invA / (invA + invB)))
+	b.Position = b.Position.Add(m.Normal.Scale(overlap * invB / (invA + invB)))
 
-	delta := positionB.Sub(positionA)
-	dist := delta.Len()
-	minDist := ca.Radius + cb.Radius
+	// manifolds hold at most two contacts, so gather them on the stack
+	var buf [2]Float2
+	points := buf[:0]
+	for _, c := range m.Contacts {
+		points = append(points, c.Point)
+	}
+	stuck := applyContactImpulse(a, b, m.Normal, points, mat)
+	a.Position = a.Position.Add(stuck.Scale(dt * invA / (invA + invB)))
+	b.Position = b.Position.Add(stuck.Scale(-dt * invB / (invA + invB)))
+}
 
-	if dist < minDist && dist > 0 {
-		// Push them appart
-		overlap := minDist - dist
-		normal := delta.Scale(1 / dist)
+// applyContactImpulse resolves the approach of b towards a along normal, and friction across it: contacts
+// stick while the static friction can hold them, and slide against the dynamic friction otherwise.
+// Two points are solved together from the same starting velocities, so a box resting flat gets the whole
+// impulse it needs and stays symmetric instead of picking up spin. When static friction holds, it returns
+// the velocity b's centre was sliding at relative to a's, which resolveManifold takes back
+func applyContactImpulse(a, b *PhysicsComponent, normal Float2, points []Float2, mat contactMaterial) Float2 {
+	n := min(len(points), 2)
+	if n == 0 {
+		return ZeroFloat2
+	}
+	invA, invB := a.InverseMass(), b.InverseMass()
+	invIA, invIB := a.InverseInertia(), b.InverseInertia()
 
-		a.Physics.Position = a.Physics.Position.Add(normal.Scale(-overlap / 2))
-		b.Physics.Position = b.Physics.Position.Add(normal.Scale(overlap / 2))
+	var ra, rb [2]Float2
+	for i, p := range points[:n] {
+		ra[i], rb[i] = p.Sub(a.Position), p.Sub(b.Position)
+	}
+	// the closures below read the first n entries of ra and rb
+	speeds := func(dir Float2) (v [2]float64) {
+		for i := range n {
+			v[i] = b.VelocityAt(rb[i]).Sub(a.VelocityAt(ra[i])).Dot(dir)
+		}
+		return v
+	}
+	// entry i, j is how much the speed at point i along dir changes per unit impulse at point j
+	coupling := func(dir Float2) (k [2][2]float64) {
+		for i := range n {
+			for j := range n {
+				k[i][j] = invA + invB + ra[i].Cross(dir)*ra[j].Cross(dir)*invIA + rb[i].Cross(dir)*rb[j].Cross(dir)*invIB
+			}
+		}
+		return k
+	}
+	apply := func(dir Float2, impulse [2]float64) {
+		for i := range n {
+			a.ApplyImpulse(dir.Scale(-impulse[i]), ra[i])
+			b.ApplyImpulse(dir.Scale(impulse[i]), rb[i])
+		}
+	}
 
-		// elastic collisions
-		relVel := b.Physics.Velocity.Sub(a.Physics.Velocity)
-		sepVel := relVel.Dot(normal)
+	// approaching points should end up at rest, or bouncing back at restitution times their speed
+	var bounce [2]float64
+	for i, v := range speeds(normal) {
+		if i < n && -v >= restingSpeed {
+			bounce[i] = -mat.restitution * v
+		}
+	}
+	tangent := Float2{X: -normal.Y, Y: normal.X}
+	kn, kt := coupling(normal), coupling(tangent)
+
+	// friction turns the body, which the normal impulses then fight, so the two are solved in turns.
+	// Each pass works out the total impulse at every point from the velocities so far
+	var pushed, rubbed [2]float64
+	drift := b.Velocity.Sub(a.Velocity).Dot(tangent) // the sliding while integrated, undone if they stick
+	sticks := false
+	for range contactPasses {
+		v := speeds(normal)
+		for i := range n {
+			v[i] -= bounce[i]
+			for j := range n {
+				v[i] -= kn[i][j] * pushed[j]
+			}
+		}
+		total := solvePushes(kn, v, n)
+		change := math.Abs(total[0]-pushed[0]) + math.Abs(total[1]-pushed[1])
+		apply(normal, [2]float64{total[0] - pushed[0], total[1] - pushed[1]})
+		pushed = total
+
+		// friction only acts at the points that push: they stop sliding entirely if static friction
+		// can hold the pair, otherwise the same impulses are scaled down to the dynamic friction
+		vt := speeds(tangent)
+		var held [2]int
+		m := 0
+		for i := range n {
+			if pushed[i] > 0 {
+				held[m] = i
+				m++
+			}
+		}
+		var k [2][2]float64
+		var w [2]float64
+		for p := range m {
+			w[p] = vt[held[p]]
+			for j := range n {
+				w[p] -= kt[held[p]][j] * rubbed[j]
+			}
+			for q := range m {
+				k[p][q] = kt[held[p]][held[q]]
+			}
+		}
+		stop := solveLinear(k, w, m)
+
+		total = [2]float64{}
+		friction, load := 0.0, pushed[0]+pushed[1]
+		for p := range m {
+			total[held[p]] = stop[p]
+			friction += stop[p]
+		}
+		sticks = m > 0 && math.Abs(friction) <= mat.staticFriction*load
+		if !sticks && friction != 0 {
+			scale := min(1, mat.dynamicFriction*load/math.Abs(friction))
+			total[0] *= scale
+			total[1] *= scale
+		}
+		change += math.Abs(total[0]-rubbed[0]) + math.Abs(total[1]-rubbed[1])
+		apply(tangent, [2]float64{total[0] - rubbed[0], total[1] - rubbed[1]})
+		rubbed = total
+
+		if change <= 1e-9*load {
+			break
+		}
+	}
+
+	if !sticks {
+		return ZeroFloat2
+	}
+	return tangent.Scale(drift)
+}
 
-		// if not going in separate directions
-		if sepVel < 0 {
-			impulse := normal.Scale(-2 * sepVel / (a.Physics.Mass + b.Physics.Mass))
-			a.Physics.Velocity = a.Physics.Velocity.Sub(impulse.Scale(a.Physics.Mass))
-			b.Physics.Velocity = b.Physics.Velocity.Add(impulse.Scale(b.Physics.Mass))
+// contactPasses caps how many times applyContactImpulse alternates between the normal and friction
+// impulses. A single point settles in a couple of passes; a box resting on an edge needs more
+const contactPasses = 16
+
+// solveLinear finds the impulses that change the speeds at n points by -v, given their coupling k. Two
+// points with nearly the same lever arm can't be told apart, so they share the impulse evenly instead
+func solveLinear(k [2][2]float64, v [2]float64, n int) (x [2]float64) {
+	if n == 2 {
+		if det := k[0][0]*k[1][1] - k[0][1]*k[1][0]; det > 1e-3*k[0][0]*k[1][1] {
+			x[0] = (k[0][1]*v[1] - k[1][1]*v[0]) / det
+			x[1] = (k[1][0]*v[0] - k[0][0]*v[1]) / det
+			return x
+		}
+	}
+	for i := range n {
+		if k[i][i] > 0 {
+			x[i] = -v[i] / k[i][i] / float64(n)
+		}
+	}
+	return x
+}
+
+// solvePushes finds non-negative impulses that leave no point with a negative speed, pushing only at
+// points that end up at speed 0. It tries both points together, then each alone
+func solvePushes(k [2][2]float64, v [2]float64, n int) (x [2]float64) {
+	if n == 2 {
+		if both := solveLinear(k, v, 2); both[0] >= 0 && both[1] >= 0 {
+			return both
+		}
+	}
+	for i := range n {
+		if v[i] >= 0 || k[i][i] <= 0 {
+			continue
+		}
+		xi := -v[i] / k[i][i]
+		if j := 1 - i; n == 2 && v[j]+k[j][i]*xi < 0 {
+			continue
 		}
+		x[i] = xi
+		return x
 	}
+	return x
 }
 
 func (e *Entity) UpdateColorBasedOnSpeed(maxSpeed float64) {
@@ -160,8 +459,14 @@ func (e *Entity) UpdateColorBasedOnSpeed(maxSpeed float64) {
 	g := 0.0
 	b := lerp(1.0, 0.0, t)
 
-	if circle, ok := e.Render.(*CircleRender); ok {
-		circle.Color = Color{Red: r, Green: g, Blue: b}
+	c := Color{Red: r, Green: g, Blue: b}
+	switch render := e.Render.(type) {
+	case *CircleRender:
+		render.Color = c
+	case *BoxRender:
+		render.Color = c
+	case *PolygonRender:
+		render.Color = c
 	}
 }
 
@@ -0,0 +1,220 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func scatter(n int, width, height float64) []*Entity {
+	rng := rand.New(rand.NewSource(42))
+	return CreateChaoticCircles(rng, n, 5, width, height, 50, 200)
+}
+
+// legacyPairs is the broad phase the grid replaced: entities bucketed by centre only, so pairs straddling
+// a cell edge are never seen. Like ForEachPair it only reports pairs whose bounds overlap
+func legacyPairs(entities []*Entity, cellSize float64, fn func(a, b *Entity)) {
+	buckets := map[[2]int][]*Entity{}
+	for _, e := range entities {
+		key := [2]int{
+			int(math.Floor(e.Physics.Position.X / cellSize)),
+			int(math.Floor(e.Physics.Position.Y / cellSize)),
+		}
+		buckets[key] = append(buckets[key], e)
+	}
+
+	for _, cell := range buckets {
+		for i := 0; i < len(cell); i++ {
+			for j := i + 1; j < len(cell); j++ {
+				if cell[i].Bounds().Overlaps(cell[j].Bounds()) {
+					fn(cell[i], cell[j])
+				}
+			}
+		}
+	}
+}
+
+func TestGridFindsEveryOverlapOnce(t *testing.T) {
+	checkGridPairs(t, scatter(2000, 1024, 512), 25)
+}
+
+// TestGridFindsEveryLargeOverlapOnce mixes radii up to 80 on a 10 unit grid, so most entities span many
+// cells and land in the serial wide pass of HandleObjectCollisions
+func TestGridFindsEveryLargeOverlapOnce(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	entities := make([]*Entity, 300)
+	for i := range entities {
+		radius := 1 + rng.Float64()*79
+		entities[i] = NewCircleEntity(rng.Float64()*1024, rng.Float64()*512, radius, 0, 1, 1, 1)
+	}
+	checkGridPairs(t, entities, 10)
+}
+
+// checkGridPairs checks the grid against brute force: every overlapping pair reported exactly once, both
+// by ForEachPair and by the narrow and wide passes together
+func checkGridPairs(t *testing.T, entities []*Entity, cellSize float64) {
+	t.Helper()
+	index := map[*Entity]int{}
+	for i, e := range entities {
+		index[e] = i
+	}
+	key := func(a, b *Entity) [2]int {
+		i, j := index[a], index[b]
+		return [2]int{min(i, j), max(i, j)}
+	}
+
+	want := map[[2]int]bool{}
+	for i, a := range entities {
+		for _, b := range entities[i+1:] {
+			if a.Bounds().Overlaps(b.Bounds()) {
+				want[key(a, b)] = true
+			}
+		}
+	}
+
+	grid := NewSpatialGrid(cellSize)
+	for _, e := range entities {
+		grid.Insert(e)
+	}
+	all := map[[2]int]int{}
+	grid.ForEachPair(func(a, b *Entity) { all[key(a, b)]++ })
+	split := map[[2]int]int{}
+	for _, filter := range []pairFilter{pairNarrow, pairWide} {
+		for _, k := range grid.order {
+			grid.cellPairs(k, filter, func(a, b *Entity) { split[key(a, b)]++ })
+		}
+	}
+
+	for name, got := range map[string]map[[2]int]int{"ForEachPair": all, "narrow and wide passes": split} {
+		for p, n := range got {
+			if n != 1 {
+				t.Errorf("%s: pair %v reported %d times", name, p, n)
+			}
+			if !want[p] {
+				t.Errorf("%s: pair %v reported without overlapping", name, p)
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s found %d pairs, want %d", name, len(got), len(want))
+		}
+	}
+}
+
+// rampWorld tilts a long static box by angle under downward gravity. on places a body of the given half
+// height resting on the ramp's top face, and normal is that face's outward normal
+func rampWorld(angle float64) (w *World, on func(e *Entity, halfHeight float64), normal Float2) {
+	w = NewWorld(WorldBounds{MinX: -2000, MinY: -2000, MaxX: 2000, MaxY: 2000}, Float2{Y: -500})
+	w.Add(NewBoxObstacle(0, 0, 3000, 20, angle, 1, 1, 1))
+	normal = Float2{X: -math.Sin(angle), Y: math.Cos(angle)}
+	on = func(e *Entity, halfHeight float64) {
+		e.Physics.Position = normal.Scale(10 + halfHeight)
+		e.Physics.Angle = angle
+		w.Add(e)
+	}
+	return w, on, normal
+}
+
+func TestCircleRollsDownRamp(t *testing.T) {
+	const radius = 10
+	w, on, normal := rampWorld(20 * math.Pi / 180)
+	ball := NewCircleEntity(0, 0, radius, 0, 1, 1, 1)
+	on(ball, radius)
+	for range 120 {
+		w.Step()
+	}
+
+	p := ball.Physics
+	speed := p.Velocity.Len()
+	if speed < 10 || p.Velocity.X >= 0 {
+		t.Fatalf("ball moving %v, want it rolling down to the left", p.Velocity)
+	}
+	if math.Abs(p.AngularVelocity*radius-speed) > 0.05*speed {
+		t.Errorf("ω·r = %v at speed %v, want rolling without slipping", p.AngularVelocity*radius, speed)
+	}
+	if slip := p.VelocityAt(normal.Scale(-radius)).Len(); slip > 0.05*speed {
+		t.Errorf("contact point slips at %v, want about 0", slip)
+	}
+}
+
+func TestBoxHeldBySlopeFriction(t *testing.T) {
+	angle := 20 * math.Pi / 180 // tan θ ≈ 0.36
+	for _, tc := range []struct {
+		friction float64
+		holds    bool
+	}{{0.5, true}, {0.1, false}} {
+		w, on, _ := rampWorld(angle)
+		w.Contacts.Default.StaticFriction = tc.friction
+		w.Contacts.Default.DynamicFriction = tc.friction
+		box := NewBoxEntity(0, 0, 20, 20, 0, 1, 1, 1)
+		on(box, 10)
+		start := box.Physics.Position
+		for range 240 {
+			w.Step()
+		}
+
+		moved := box.Physics.Position.Sub(start).Len()
+		if tc.holds && moved > 0.1 {
+			t.Errorf("static friction %v: box slid %v, want it held on a slope of tan %.2f",
+				tc.friction, moved, math.Tan(angle))
+		}
+		if !tc.holds && moved < 10 {
+			t.Errorf("static friction %v: box moved only %v, want it sliding", tc.friction, moved)
+		}
+	}
+}
+
+func TestOffCentreHitSpinsBox(t *testing.T) {
+	for _, tc := range []struct {
+		offset float64
+		spin   float64 // sign of the box's angular velocity after the hit
+	}{{5, -1}, {-5, 1}} {
+		box := NewBoxEntity(0, 0, 20, 20, 0, 1, 1, 1)
+		ball := NewCircleEntity(-14, tc.offset, 2, 0, 1, 1, 1)
+		ball.Physics.Velocity = Float2{X: 100}
+		w := NewWorld(WorldBounds{MinX: -100, MinY: -100, MaxX: 100, MaxY: 100}, ZeroFloat2)
+		w.Add(box, ball)
+		for range 10 {
+			w.Step()
+		}
+
+		if got := box.Physics.AngularVelocity; got*tc.spin <= 0 {
+			t.Errorf("hit %v above the centre spins the box at %v, want the sign of %v", tc.offset, got, tc.spin)
+		}
+		if box.Physics.Velocity.X <= 0 {
+			t.Errorf("hit %v above the centre left the box moving %v, want it pushed along +x",
+				tc.offset, box.Physics.Velocity)
+		}
+	}
+}
+
+// BenchmarkBroadPhase finds the overlapping pairs of n circles with the old centre-bucketed map and with
+// the grid, reporting how many each found
+func BenchmarkBroadPhase(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		entities := scatter(n, 2048, 1024)
+
+		b.Run(fmt.Sprintf("legacy/n=%d", n), func(b *testing.B) {
+			pairs := 0
+			for range b.N {
+				pairs = 0
+				legacyPairs(entities, 25, func(a, b *Entity) { pairs++ })
+			}
+			b.ReportMetric(float64(pairs), "pairs")
+		})
+
+		b.Run(fmt.Sprintf("grid/n=%d", n), func(b *testing.B) {
+			grid := NewSpatialGrid(25)
+			pairs := 0
+			for range b.N {
+				pairs = 0
+				grid.Clear()
+				for _, e := range entities {
+					grid.Insert(e)
+				}
+				grid.ForEachPair(func(a, b *Entity) { pairs++ })
+			}
+			b.ReportMetric(float64(pairs), "pairs")
+		})
+	}
+}
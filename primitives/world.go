@@ -0,0 +1,166 @@
+package primitives
+
+import (
+	"runtime"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/gopxl/pixel/v2/ext/imdraw"
+)
+
+// World owns the simulated entities and steps them at a fixed timestep, independent of the frame rate.
+// Frame time is accumulated and consumed in FixedDT steps, each split into Substeps, and the leftover
+// fraction is exposed through Alpha so rendering can interpolate between the last two steps
+type World struct {
+	Entities    []*Entity
+	Constraints []Constraint
+	Links       []RenderComponet // drawn under the entities, typically LinkRender
+	Gravity     Float2
+	Fields      []ForceField
+	Integrator  Integrator // nil is SemiImplicitEuler; entities can override it
+	Bounds      WorldBounds
+	Grid        *SpatialGrid
+	Contacts    ContactRules
+
+	FixedDT    float64 // seconds per step
+	Substeps   int     // integration passes per step
+	Iterations int     // contact solver passes per substep; stacks of boxes want 4 or more
+	MaxSteps   int     // steps per Advance before the backlog is dropped, to survive frame hitches
+	Workers    int     // goroutines sharing each step; results don't depend on it
+
+	accumulator float64
+	previous    []Float2  // positions before the last step, aligned with Entities by track
+	prevAngle   []float64 // angles before the last step
+	time        float64
+}
+
+func NewWorld(bounds WorldBounds, gravity Float2) *World {
+	return &World{
+		Gravity:    gravity,
+		Bounds:     bounds,
+		Grid:       NewSpatialGrid(25),
+		Contacts:   DefaultContactRules(),
+		FixedDT:    1.0 / 120,
+		Substeps:   1,
+		Iterations: 1,
+		MaxSteps:   8,
+		Workers:    runtime.GOMAXPROCS(0),
+	}
+}
+
+func (w *World) Add(entities ...*Entity) {
+	for _, e := range entities {
+		w.Entities = append(w.Entities, e)
+		w.previous = append(w.previous, e.Physics.Position)
+		w.prevAngle = append(w.prevAngle, e.Physics.Angle)
+	}
+}
+
+// AddConstraint adds a constraint, and its render when it isn't nil
+func (w *World) AddConstraint(c Constraint, render RenderComponet) {
+	w.Constraints = append(w.Constraints, c)
+	if render != nil {
+		w.Links = append(w.Links, render)
+	}
+}
+
+// Reset replaces every entity, drops the constraints and clears the accumulated time
+func (w *World) Reset(entities []*Entity) {
+	w.Entities = w.Entities[:0]
+	w.Constraints = w.Constraints[:0]
+	w.Links = w.Links[:0]
+	w.previous = w.previous[:0]
+	w.prevAngle = w.prevAngle[:0]
+	w.accumulator = 0
+	w.time = 0
+	w.Add(entities...)
+}
+
+// Advance adds frame time and runs as many fixed steps as fit, returning how many ran
+func (w *World) Advance(dt float64) int {
+	w.accumulator += dt
+
+	steps := 0
+	for w.accumulator >= w.FixedDT {
+		if w.MaxSteps > 0 && steps >= w.MaxSteps {
+			// too far behind, drop the rest instead of spiralling
+			w.accumulator = 0
+			break
+		}
+		w.Step()
+		w.accumulator -= w.FixedDT
+		steps++
+	}
+	return steps
+}
+
+// track keeps the previous poses aligned with Entities, which callers may also change directly.
+// Entities without a previous pose start from their current one
+func (w *World) track() {
+	for _, e := range w.Entities[min(len(w.previous), len(w.Entities)):] {
+		w.previous = append(w.previous, e.Physics.Position)
+		w.prevAngle = append(w.prevAngle, e.Physics.Angle)
+	}
+	w.previous = w.previous[:len(w.Entities)]
+	w.prevAngle = w.prevAngle[:len(w.Entities)]
+}
+
+// Step runs exactly one fixed step
+func (w *World) Step() {
+	w.track()
+	for i, e := range w.Entities {
+		w.previous[i] = e.Physics.Position
+		w.prevAngle[i] = e.Physics.Angle
+	}
+
+	substeps := max(w.Substeps, 1)
+	dt := w.FixedDT / float64(substeps)
+	iterations := max(w.Iterations, 1)
+	dynamics := Dynamics{Gravity: w.Gravity, Fields: w.Fields, Integrator: w.Integrator}
+	for range substeps {
+		for _, c := range w.Constraints {
+			c.PreStep(dt)
+		}
+		UpdateEntities(w.Entities, w.Grid, dynamics, w.Bounds, w.Contacts, dt, iterations, w.Workers)
+		for range iterations {
+			for _, c := range w.Constraints {
+				c.Solve(dt)
+			}
+		}
+	}
+	w.time += w.FixedDT
+}
+
+// Time is the simulated time in seconds
+func (w *World) Time() float64 {
+	return w.time
+}
+
+// Alpha is how far between the last step and the next the current frame is, in [0, 1)
+func (w *World) Alpha() float64 {
+	return w.accumulator / w.FixedDT
+}
+
+// InterpolatedPosition blends entity i between its previous and current position by Alpha
+func (w *World) InterpolatedPosition(i int) Float2 {
+	w.track()
+	prev := w.previous[i]
+	cur := w.Entities[i].Physics.Position
+	alpha := w.Alpha()
+	return Float2{X: lerp(prev.X, cur.X, alpha), Y: lerp(prev.Y, cur.Y, alpha)}
+}
+
+// InterpolatedAngle blends entity i between its previous and current angle by Alpha
+func (w *World) InterpolatedAngle(i int) float64 {
+	w.track()
+	return lerp(w.prevAngle[i], w.Entities[i].Physics.Angle, w.Alpha())
+}
+
+// Draw renders the links, then every entity at its interpolated position and angle
+func (w *World) Draw(imd *imdraw.IMDraw) {
+	for _, l := range w.Links {
+		l.Draw(imd, pixel.ZV)
+	}
+	for i, e := range w.Entities {
+		DrawRender(imd, e.Render, pixel.Vec(w.InterpolatedPosition(i)), w.InterpolatedAngle(i))
+	}
+}
@@ -0,0 +1,120 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFieldsDoNotDependOnFrameRate(t *testing.T) {
+	run := func(frames int) Float2 {
+		w := NewWorld(WorldBounds{MinX: -1000, MinY: -1000, MaxX: 1000, MaxY: 1000}, ZeroFloat2)
+		w.FixedDT = 1.0 / 64
+		w.MaxSteps = 0
+		e := NewCircleEntity(100, 0, 1, 0, 1, 1, 1)
+		w.Add(e)
+		w.Fields = append(w.Fields, CircularField(ZeroFloat2, 300, 5000))
+		for range frames {
+			w.Advance(0.125 / float64(frames))
+		}
+		return e.Physics.Velocity
+	}
+
+	one, many := run(1), run(4)
+	if math.Abs(one.X-many.X) > 1e-9 || math.Abs(one.Y-many.Y) > 1e-9 {
+		t.Errorf("velocity after one frame %v, after four %v, want the same", one, many)
+	}
+	if one.X >= 0 {
+		t.Errorf("velocity %v, want a pull towards the centre", one)
+	}
+}
+
+// circleWorld drops n circles into a world that keeps the playground window's density as n grows
+func circleWorld(n, workers int) *World {
+	scale := math.Sqrt(float64(n) / 1000)
+	width, height := 1024*scale, 512*scale
+
+	w := NewWorld(WorldBounds{MaxX: width, MaxY: height}, Float2{X: 0, Y: -200})
+	w.Workers = workers
+	w.Add(scatter(n, width, height)...)
+	return w
+}
+
+// BenchmarkStep times one fixed step of n circles, once they have settled into contact, on several worker
+// counts
+func BenchmarkStep(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		for _, workers := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				w := circleWorld(n, workers)
+				for range 30 {
+					w.Step()
+				}
+				b.ResetTimer()
+				for range b.N {
+					w.Step()
+				}
+			})
+		}
+	}
+}
+
+func TestStepDoesNotDependOnWorkers(t *testing.T) {
+	state := func(workers int) []Float2 {
+		w := circleWorld(2000, workers)
+		for range 60 {
+			w.Step()
+		}
+		var out []Float2
+		for _, e := range w.Entities {
+			out = append(out, e.Physics.Position, e.Physics.Velocity, Float2{X: e.Physics.Angle, Y: e.Physics.AngularVelocity})
+		}
+		return out
+	}
+
+	want := state(1)
+	for _, workers := range []int{2, 8} {
+		got := state(workers)
+		for i := range want {
+			// bitwise equal, not just close
+			if got[i] != want[i] {
+				t.Errorf("workers=%d: entity %d state %v, want %v", workers, i/3, got[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestStepTracksEntitiesAppendedDirectly(t *testing.T) {
+	w := NewWorld(WorldBounds{MaxX: 100, MaxY: 100}, ZeroFloat2)
+	w.Add(NewCircleEntity(20, 50, 1, 0, 1, 1, 1))
+	w.Entities = append(w.Entities, NewCircleEntity(80, 50, 1, 0, 1, 1, 1))
+
+	if got := w.InterpolatedPosition(1); got != (Float2{X: 80, Y: 50}) {
+		t.Errorf("appended entity interpolates to %v before a step, want its position", got)
+	}
+	w.Step()
+	w.Entities = w.Entities[:1]
+	w.Step()
+	if got := w.InterpolatedAngle(0); got != 0 {
+		t.Errorf("angle %v after removing an entity, want 0", got)
+	}
+}
+
+func TestStepKeepsEntityColors(t *testing.T) {
+	w := NewWorld(WorldBounds{MaxX: 100, MaxY: 100}, Float2{X: 0, Y: -200})
+	box := NewBoxEntity(30, 50, 10, 10, 0, 0.2, 0.8, 0.1)
+	poly := NewPolygonEntity(70, 50, BoxVertices(Float2{X: 4, Y: 4}), 0, 0.9, 0.9, 0.3)
+	box.Physics.Velocity = Float2{X: 500}
+	w.Add(box, poly)
+
+	for range 10 {
+		w.Step()
+	}
+	if got, want := box.Render.(*BoxRender).Color, (Color{Red: 0.2, Green: 0.8, Blue: 0.1}); got != want {
+		t.Errorf("box color %v after stepping, want %v", got, want)
+	}
+	if got, want := poly.Render.(*PolygonRender).Color, (Color{Red: 0.9, Green: 0.9, Blue: 0.3}); got != want {
+		t.Errorf("polygon color %v after stepping, want %v", got, want)
+	}
+}
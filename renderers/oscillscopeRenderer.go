@@ -1,12 +1,13 @@
 package renderers
 
 import (
+	"image"
 	"image/color"
 
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/backends/opengl"
 	"github.com/gopxl/pixel/v2/ext/imdraw"
 	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
 	"github.com/mykeelium/visual-playground/sources"
 )
 
@@ -17,70 +18,115 @@ const (
 	OscilloscopeXYRenderer   OscilloscopeRendererMode = "xy"
 )
 
+// OscilloscopeRenderer draws samples through a PhosphorBuffer, either as an XY figure or, in
+// OscilloscopeTimeRenderer mode, as triggered Y-T sweeps from Time over a graticule.
+// Params.Decay is the persistence in seconds and Params.Gain scales the beam intensity
 type OscilloscopeRenderer struct {
-	Params *sources.ScopeParams
-
-	canvas *opengl.Canvas
-	imd    *imdraw.IMDraw
+	Params   *sources.ScopeParams
+	Phosphor *PhosphorBuffer
+	Mode     OscilloscopeRendererMode
+	Time     *TimeScope
+
+	intensity float64
+	sweeps    int
+	imd       *imdraw.IMDraw
+
+	// reused every frame so only the pixels are refreshed, not the picture and sprite
+	picture *pixel.PictureData
+	sprite  *pixel.Sprite
 }
 
 func (r *OscilloscopeRenderer) BeginFrame(dt float64) {
-	if r.canvas == nil {
-		return
+	if r.Phosphor == nil {
+		return // will be created in EndFrame
 	}
-	if r.imd == nil {
-		r.imd = imdraw.New(nil)
+	if r.intensity == 0 {
+		r.intensity = r.Phosphor.Intensity
 	}
 
-	decay := pixel.Clamp(r.Params.Decay, 0, 1)
-
-	r.canvas.SetComposeMethod(pixel.ComposeOver)
-	r.canvas.Clear(color.RGBA{
-		R: 0,
-		G: 0,
-		B: 0,
-		A: uint8(255 * (1 - decay)),
-	})
-
-	r.imd.Clear()
+	r.Phosphor.Persistence = max(r.Params.Decay, 0)
+	r.Phosphor.Intensity = r.intensity * max(r.Params.Gain, 0)
+	r.Phosphor.Decay(dt)
 }
 
 func (r *OscilloscopeRenderer) Draw(samples []sources.Sample) {
-	if r.canvas == nil {
+	if r.Phosphor == nil {
 		return // will be created in EndFrame
 	}
 
-	bounds := r.canvas.Bounds()
-	center := bounds.Center()
-	scale := 0.45 * min(bounds.W(), bounds.H())
+	w, h := r.Phosphor.Size()
+	center := primitives.Float2{X: float64(w) / 2, Y: float64(h) / 2}
 
-	r.imd.Color = color.RGBA{
-		R: uint8(255 * pixel.Clamp(r.Params.Gain, 0, 1)),
-		G: 255,
-		B: 255,
-		A: 255,
-	}
+	if r.Mode == OscilloscopeTimeRenderer && r.Time != nil {
+		r.Time.Push(samples)
+		if r.Time.Sweeps() == r.sweeps {
+			return // the beam only writes when a new sweep completes
+		}
+		r.sweeps = r.Time.Sweeps()
 
-	for _, s := range samples {
-		x := center.X + s.XY.X*scale
-		y := center.Y + s.XY.Y*scale
-		r.imd.Push(pixel.V(x, y))
-		r.imd.Circle(1.0, 0)
+		transform := primitives.IM.
+			ScaledXY(primitives.ZeroFloat2, center).
+			Moved(center)
+		r.Phosphor.Deposit(r.Time.XY(), transform)
+		return
 	}
 
-	r.imd.Draw(r.canvas)
+	scale := 0.45 * min(float64(w), float64(h))
+	transform := primitives.IM.
+		ScaledXY(primitives.ZeroFloat2, primitives.Float2{X: scale, Y: scale}).
+		Moved(center)
+	r.Phosphor.Deposit(samples, transform)
 }
 
-func (r *OscilloscopeRenderer) EndFrame(win *opengl.Window) {
-	if r.canvas == nil {
-		r.canvas = opengl.NewCanvas(win.Bounds())
-		r.canvas.Clear(color.Black)
+// ScopeTarget is what OscilloscopeRenderer draws onto, such as an opengl.Window or a pixel.Canvas. Taking
+// the interface keeps this package free of the GL backend, so headless builds don't need cgo
+type ScopeTarget interface {
+	pixel.Target
+	Bounds() pixel.Rect
+}
+
+func (r *OscilloscopeRenderer) EndFrame(win ScopeTarget) {
+	bounds := win.Bounds()
+	if r.Phosphor == nil {
+		r.Phosphor = NewPhosphorBuffer(int(bounds.W()), int(bounds.H()), PhosphorP1)
 	}
+	// follow the target when it is resized; Resize keeps the buffer when the size is unchanged
+	r.Phosphor.Resize(int(bounds.W()), int(bounds.H()))
+
+	r.present(r.Phosphor.Image())
+	r.sprite.Draw(win, pixel.IM.Moved(bounds.Center()))
+
+	if r.Mode == OscilloscopeTimeRenderer && r.Time != nil {
+		if r.imd == nil {
+			r.imd = imdraw.New(nil)
+		}
+		r.imd.Clear()
+		graticule := r.Time.GraticuleMesh(bounds.W(), bounds.H())
+		r.imd.Color = pixel.RGB(graticule.Color.Red, graticule.Color.Green, graticule.Color.Blue)
+		for i := 1; i < len(graticule.Vertices); i += 2 {
+			a, b := graticule.Vertices[i-1], graticule.Vertices[i]
+			r.imd.Push(pixel.V(a.X, a.Y), pixel.V(b.X, b.Y))
+			r.imd.Line(1)
+		}
+		r.imd.Draw(win)
+	}
+}
 
-	r.canvas.Draw(
-		win,
-		pixel.IM.Moved(win.Bounds().Center()),
-	)
+// present copies the phosphor image into the reused picture, flipping it to pixel's y up rows
+func (r *OscilloscopeRenderer) present(img *image.RGBA) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if r.picture == nil || r.picture.Stride != w || len(r.picture.Pix) != w*h {
+		r.picture = pixel.MakePictureData(pixel.R(0, 0, float64(w), float64(h)))
+		r.sprite = pixel.NewSprite(r.picture, r.picture.Bounds())
+	}
+
+	for y := range h {
+		src := img.Pix[y*img.Stride : y*img.Stride+4*w]
+		dst := r.picture.Pix[(h-1-y)*w : (h-y)*w]
+		for x := range dst {
+			dst[x] = color.RGBA{R: src[4*x], G: src[4*x+1], B: src[4*x+2], A: src[4*x+3]}
+		}
+	}
 }
 
 func Oscilloscope(mesh meshes.MeshID) RenderFn {
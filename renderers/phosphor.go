@@ -0,0 +1,262 @@
+package renderers
+
+import (
+	"image"
+	"math"
+
+	"github.com/mykeelium/visual-playground/primitives"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+// Phosphor describes the glow colour of a CRT phosphor and its typical persistence
+type Phosphor struct {
+	Name        string
+	Color       primitives.Color
+	Persistence float64 // seconds for the glow to fall to 1/e
+}
+
+var (
+	PhosphorP1  = Phosphor{Name: "P1", Color: primitives.Color{Red: 0.25, Green: 1.0, Blue: 0.3}, Persistence: 0.25}
+	PhosphorP4  = Phosphor{Name: "P4", Color: primitives.Color{Red: 0.95, Green: 0.95, Blue: 1.0}, Persistence: 0.1}
+	PhosphorP7  = Phosphor{Name: "P7", Color: primitives.Color{Red: 0.55, Green: 0.75, Blue: 1.0}, Persistence: 1.5}
+	PhosphorP31 = Phosphor{Name: "P31", Color: primitives.Color{Red: 0.4, Green: 1.0, Blue: 0.5}, Persistence: 0.08}
+)
+
+// PhosphorBuffer models a CRT screen as a float energy image. The beam deposits energy proportional to
+// the time it dwells on each pixel (a slow beam is brighter) and the energy decays exponentially in
+// seconds, so the result does not depend on the frame rate. It is pure Go and works headless
+type PhosphorBuffer struct {
+	Phosphor    Phosphor
+	Persistence float64 // seconds for the glow to fall to 1/e; <= 0 clears every frame
+	Intensity   float64 // energy deposited per second of beam dwell
+	Exposure    float64 // scales energy before tone mapping
+	Bloom       float64 // strength of the blurred glow added around bright traces
+	BloomRadius int     // in pixels
+
+	width, height int
+	energy        []float64
+	scratch       []float64
+	line          []float64 // one row or column, for blurring
+	img           *image.RGBA
+}
+
+func NewPhosphorBuffer(width, height int, phosphor Phosphor) *PhosphorBuffer {
+	p := &PhosphorBuffer{
+		Phosphor:    phosphor,
+		Persistence: phosphor.Persistence,
+		Intensity:   3000,
+		Exposure:    1,
+		Bloom:       0.6,
+		BloomRadius: 4,
+	}
+	p.Resize(width, height)
+	return p
+}
+
+// Resize clears the buffer if the size changes
+func (p *PhosphorBuffer) Resize(width, height int) {
+	if width == p.width && height == p.height {
+		return
+	}
+	p.width, p.height = width, height
+	p.energy = make([]float64, width*height)
+	p.scratch = make([]float64, width*height)
+	p.line = make([]float64, max(width, height))
+	p.img = image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+func (p *PhosphorBuffer) Size() (int, int) {
+	return p.width, p.height
+}
+
+// Energy exposes the raw accumulation buffer, row-major with y up, for numeric comparisons
+func (p *PhosphorBuffer) Energy() []float64 {
+	return p.energy
+}
+
+func (p *PhosphorBuffer) Clear() {
+	clear(p.energy)
+}
+
+// Decay fades the stored energy by dt seconds of persistence
+func (p *PhosphorBuffer) Decay(dt float64) {
+	if p.Persistence <= 0 {
+		p.Clear()
+		return
+	}
+
+	k := math.Exp(-dt / p.Persistence)
+	for i := range p.energy {
+		p.energy[i] *= k
+	}
+}
+
+// Deposit traces the beam through the samples, with XY mapped to pixels (y up) by transform.
+// Each segment carries Intensity times its duration, spread along its length, and is pre-decayed by its
+// age relative to the last sample so the trace matches what a continuous decay would have left
+func (p *PhosphorBuffer) Deposit(samples []sources.Sample, transform primitives.Matrix) {
+	if len(samples) < 2 {
+		return
+	}
+
+	tEnd := samples[len(samples)-1].T
+	prev := transform.Project(primitives.Float2(samples[0].XY))
+	for i := 1; i < len(samples); i++ {
+		s := samples[i]
+		cur := transform.Project(primitives.Float2(s.XY))
+
+		dwell := s.T - samples[i-1].T
+		if dwell <= 0 {
+			prev = cur
+			continue
+		}
+
+		energy := p.Intensity * dwell
+		if p.Persistence > 0 {
+			energy *= math.Exp(-(tEnd - s.T) / p.Persistence)
+		}
+		p.segment(prev, cur, energy)
+		prev = cur
+	}
+}
+
+// segment spreads energy evenly along a line in roughly half pixel steps. Non-finite endpoints are
+// skipped, and a line far off screen is walked in no more steps than the buffer's diagonal needs
+func (p *PhosphorBuffer) segment(a, b primitives.Float2, energy float64) {
+	if !finite(a) || !finite(b) {
+		return
+	}
+	d := b.Sub(a)
+	maxSteps := 4 * int(math.Ceil(math.Hypot(float64(p.width), float64(p.height))))
+	steps := max(1, int(math.Ceil(min(d.Len()*2, float64(maxSteps)))))
+	share := energy / float64(steps)
+
+	for i := range steps {
+		t := (float64(i) + 0.5) / float64(steps)
+		p.splat(a.Add(d.Scale(t)), share)
+	}
+}
+
+func finite(v primitives.Float2) bool {
+	return !math.IsNaN(v.X) && !math.IsNaN(v.Y) && !math.IsInf(v.X, 0) && !math.IsInf(v.Y, 0)
+}
+
+// splat adds energy at a sub-pixel position, split bilinearly over the four nearest pixels
+func (p *PhosphorBuffer) splat(pos primitives.Float2, energy float64) {
+	x := pos.X - 0.5
+	y := pos.Y - 0.5
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	p.add(x0, y0, energy*(1-fx)*(1-fy))
+	p.add(x0+1, y0, energy*fx*(1-fy))
+	p.add(x0, y0+1, energy*(1-fx)*fy)
+	p.add(x0+1, y0+1, energy*fx*fy)
+}
+
+func (p *PhosphorBuffer) add(x, y int, energy float64) {
+	if x < 0 || y < 0 || x >= p.width || y >= p.height {
+		return
+	}
+	p.energy[y*p.width+x] += energy
+}
+
+// Image tone maps the energy into the phosphor colour and adds bloom. The returned image is reused
+func (p *PhosphorBuffer) Image() *image.RGBA {
+	glow := p.scratch
+	if p.Bloom > 0 && p.BloomRadius > 0 {
+		copy(glow, p.energy)
+		// two box blur passes approximate a gaussian
+		for range 2 {
+			boxBlur(glow, p.line, p.width, p.height, p.BloomRadius)
+		}
+	}
+	bloom := p.Bloom > 0 && p.BloomRadius > 0
+
+	c := p.Phosphor.Color
+	for y := range p.height {
+		row := (p.height - 1 - y) * p.width // energy is y up, the image is y down
+		pix := p.img.Pix[y*p.img.Stride : y*p.img.Stride+4*p.width]
+		for x := range p.width {
+			v := tone(p.energy[row+x] * p.Exposure)
+			if bloom {
+				v = math.Min(v+p.Bloom*tone(glow[row+x]*p.Exposure), 1)
+			}
+
+			px := pix[4*x : 4*x+4 : 4*x+4]
+			px[0] = uint8(255 * v * c.Red)
+			px[1] = uint8(255 * v * c.Green)
+			px[2] = uint8(255 * v * c.Blue)
+			px[3] = 255
+		}
+	}
+	return p.img
+}
+
+const (
+	toneSteps = 1024
+	toneMax   = 8 // 1 - e^-8 is within 0.04% of white
+)
+
+// toneCurve samples 1 - e^-x over [0, toneMax], so tone mapping a frame needs no exp per pixel
+var toneCurve = func() [toneSteps + 1]float64 {
+	var curve [toneSteps + 1]float64
+	for i := range curve {
+		curve[i] = 1 - math.Exp(-float64(i)*toneMax/toneSteps)
+	}
+	return curve
+}()
+
+// tone is 1 - e^-e, the fraction of full brightness energy e lights a pixel to
+func tone(e float64) float64 {
+	if e <= 0 {
+		return 0
+	}
+	if e >= toneMax {
+		return 1
+	}
+	f := e * toneSteps / toneMax
+	i := int(f)
+	return toneCurve[i] + (toneCurve[i+1]-toneCurve[i])*(f-float64(i))
+}
+
+// boxBlur blurs buf in place horizontally then vertically with the given radius, using line as scratch
+// at least as long as a row or column
+func boxBlur(buf, line []float64, width, height, radius int) {
+	norm := 1 / float64(2*radius+1)
+
+	for y := range height {
+		row := buf[y*width : (y+1)*width]
+		copy(line, row)
+		blurLine(row, line[:width], 1, radius, norm)
+	}
+
+	for x := range width {
+		for y := range height {
+			line[y] = buf[y*width+x]
+		}
+		col := buf[x:]
+		blurLine(col, line[:height], width, radius, norm)
+	}
+}
+
+// blurLine writes the running box average of src into dst with the given stride
+func blurLine(dst []float64, src []float64, stride, radius int, norm float64) {
+	n := len(src)
+	sum := 0.0
+	for i := -radius; i <= radius; i++ {
+		if i >= 0 && i < n {
+			sum += src[i]
+		}
+	}
+
+	for i := range n {
+		dst[i*stride] = sum * norm
+		if out := i - radius; out >= 0 {
+			sum -= src[out]
+		}
+		if in := i + radius + 1; in < n {
+			sum += src[in]
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package renderers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/mykeelium/visual-playground/primitives"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+func TestToneMatchesExp(t *testing.T) {
+	for e := 0.0; e < 10; e += 0.013 {
+		if got, want := tone(e), 1-math.Exp(-e); math.Abs(got-want) > 1e-3 {
+			t.Fatalf("tone(%v) = %v, want %v", e, got, want)
+		}
+	}
+}
+
+func litBuffer() *PhosphorBuffer {
+	p := NewPhosphorBuffer(64, 48, PhosphorP1)
+	samples := []sources.Sample{
+		{T: 0, XY: sources.XY{X: 5, Y: 5}},
+		{T: 0.01, XY: sources.XY{X: 50, Y: 40}},
+		{T: 0.02, XY: sources.XY{X: 60, Y: 8}},
+	}
+	p.Deposit(samples, primitives.IM)
+	return p
+}
+
+func TestPhosphorImageDoesNotAllocate(t *testing.T) {
+	p := litBuffer()
+	if allocs := testing.AllocsPerRun(10, func() { p.Image() }); allocs != 0 {
+		t.Errorf("Image allocated %v times per frame, want 0", allocs)
+	}
+}
+
+func TestPresentMatchesPictureData(t *testing.T) {
+	img := litBuffer().Image()
+
+	var r OscilloscopeRenderer
+	r.present(img)
+	want := pixel.PictureDataFromImage(img)
+	if r.picture.Stride != want.Stride || len(r.picture.Pix) != len(want.Pix) {
+		t.Fatalf("picture is %d wide with %d pixels, want %d and %d", r.picture.Stride, len(r.picture.Pix), want.Stride, len(want.Pix))
+	}
+	for i := range want.Pix {
+		if r.picture.Pix[i] != want.Pix[i] {
+			t.Fatalf("pixel %d = %v, want %v", i, r.picture.Pix[i], want.Pix[i])
+		}
+	}
+
+	// a second frame reuses the picture
+	picture := r.picture
+	if allocs := testing.AllocsPerRun(10, func() { r.present(img) }); allocs != 0 || r.picture != picture {
+		t.Errorf("present allocated %v times or replaced the picture", allocs)
+	}
+}
+
+// afterOneSecond runs a slowly drifting beam for one second, split into frames at fps the way the
+// renderer does: decay by the frame's span, then deposit the frame's samples plus the previous last one
+func afterOneSecond(fps int) float64 {
+	const rate = 12000
+	samples := make([]sources.Sample, rate+1)
+	for i := range samples {
+		t := float64(i) / rate
+		samples[i] = sources.Sample{T: t, XY: sources.XY{X: 10 + 20*t, Y: 12}}
+	}
+
+	p := NewPhosphorBuffer(40, 24, PhosphorP1)
+	lo := 0
+	for f := 1; f <= fps; f++ {
+		hi := f * rate / fps
+		p.Decay(samples[hi].T - samples[lo].T)
+		p.Deposit(samples[lo:hi+1], primitives.IM)
+		lo = hi
+	}
+
+	total := 0.0
+	for _, e := range p.Energy() {
+		total += e
+	}
+	return total
+}
+
+func TestDecayIsFrameRateIndependent(t *testing.T) {
+	slow, fast := afterOneSecond(30), afterOneSecond(144)
+	if slow == 0 || math.Abs(slow-fast) > 1e-9*slow {
+		t.Errorf("energy after 1s: %v at 30 fps, %v at 144 fps", slow, fast)
+	}
+}
+
+func TestSlowBeamIsBrighter(t *testing.T) {
+	p := NewPhosphorBuffer(100, 20, PhosphorP1)
+	p.Persistence = 0 // no pre-decay, so the energy is the dwell alone
+	sweep := func(y, seconds float64) {
+		p.Deposit([]sources.Sample{
+			{T: 0, XY: sources.XY{X: 10, Y: y}},
+			{T: seconds, XY: sources.XY{X: 90, Y: y}},
+		}, primitives.IM)
+	}
+	sweep(5.5, 0.1)
+	sweep(15.5, 0.01)
+
+	slow, fast := p.Energy()[5*100+50], p.Energy()[15*100+50]
+	if fast <= 0 || math.Abs(slow/fast-10) > 1e-6 {
+		t.Errorf("slow beam energy %v, fast beam %v, want a ratio of 10", slow, fast)
+	}
+}
+
+func TestDepositSkipsWildSamples(t *testing.T) {
+	p := NewPhosphorBuffer(32, 32, PhosphorP1)
+	p.Deposit([]sources.Sample{
+		{T: 0, XY: sources.XY{X: 4, Y: 4}},
+		{T: 0.01, XY: sources.XY{X: math.NaN(), Y: 4}},
+		{T: 0.02, XY: sources.XY{X: math.Inf(1), Y: 4}},
+		{T: 0.03, XY: sources.XY{X: 1e300, Y: -1e300}},
+		{T: 0.04, XY: sources.XY{X: 20, Y: 20}},
+	}, primitives.IM)
+
+	for i, e := range p.Energy() {
+		if math.IsNaN(e) || math.IsInf(e, 0) {
+			t.Fatalf("energy[%d] = %v", i, e)
+		}
+	}
+}
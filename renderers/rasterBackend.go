@@ -0,0 +1,167 @@
+package renderers
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+// RasterBackend is a headless RenderBackend that rasterizes meshes into an image.RGBA in pure Go,
+// so frames can be produced and saved without a window or GPU
+type RasterBackend struct {
+	Thickness  float64 // line width / point diameter in pixels
+	Color      color.Color
+	Background color.Color
+
+	registry *meshes.MeshRegistry
+	img      *image.RGBA
+}
+
+func NewRasterBackend(registry *meshes.MeshRegistry, width, height int) *RasterBackend {
+	return &RasterBackend{
+		Thickness:  1,
+		Color:      color.White,
+		Background: color.Black,
+		registry:   registry,
+		img:        image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+}
+
+func (b *RasterBackend) BeginFrame(fc *FrameContext) {
+	// follow the frame size when one is given, otherwise keep the constructed size
+	w, h := int(fc.Size.X), int(fc.Size.Y)
+	if w > 0 && h > 0 && (w != b.img.Rect.Dx() || h != b.img.Rect.Dy()) {
+		b.img = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+
+	bg := color.RGBAModel.Convert(b.Background).(color.RGBA)
+	pix := b.img.Pix
+	for i := 0; i < len(pix); i += 4 {
+		pix[i+0] = bg.R
+		pix[i+1] = bg.G
+		pix[i+2] = bg.B
+		pix[i+3] = bg.A
+	}
+}
+
+func (b *RasterBackend) DrawMesh(meshID meshes.MeshID, transform primitives.Matrix) {
+	mesh := b.registry.Get(meshID)
+	col := color.RGBAModel.Convert(b.Color).(color.RGBA)
+	if mesh.Color != nil {
+		col = color.RGBA{
+			R: uint8(255 * clamp01(mesh.Color.Red)),
+			G: uint8(255 * clamp01(mesh.Color.Green)),
+			B: uint8(255 * clamp01(mesh.Color.Blue)),
+			A: 255,
+		}
+	}
+	thickness := b.Thickness
+	if mesh.Thickness > 0 {
+		thickness = mesh.Thickness
+	}
+	halfWidth := max(thickness, 1) / 2
+
+	if mesh.Mode == meshes.DrawModePoint {
+		for _, v := range mesh.Vertices {
+			p := b.toImage(transform.Project(v))
+			b.segment(p, p, halfWidth, col)
+		}
+		return
+	}
+
+	step := 1
+	if mesh.Mode == meshes.DrawModeSegments {
+		step = 2
+	}
+	for i := 1; i < len(mesh.Vertices); i += step {
+		p0 := b.toImage(transform.Project(mesh.Vertices[i-1]))
+		p1 := b.toImage(transform.Project(mesh.Vertices[i]))
+		b.segment(p0, p1, halfWidth, col)
+	}
+}
+
+func (b *RasterBackend) EndFrame(fc *FrameContext) {}
+
+// Image returns the most recently rasterized frame
+func (b *RasterBackend) Image() *image.RGBA {
+	return b.img
+}
+
+func (b *RasterBackend) WritePNG(w io.Writer) error {
+	return png.Encode(w, b.img)
+}
+
+func (b *RasterBackend) SavePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := b.WritePNG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// toImage flips world coordinates (y up, like pixel) into image coordinates (y down)
+func (b *RasterBackend) toImage(p primitives.Float2) primitives.Float2 {
+	return primitives.Float2{X: p.X, Y: float64(b.img.Rect.Dy()) - p.Y}
+}
+
+// segment draws an anti-aliased capsule from p0 to p1; coverage falls off over one pixel at the edge.
+// Non-finite endpoints draw nothing
+func (b *RasterBackend) segment(p0, p1 primitives.Float2, halfWidth float64, col color.RGBA) {
+	if !finite(p0) || !finite(p1) {
+		return
+	}
+	bounds := b.img.Rect
+	pad := halfWidth + 1
+	x0 := max(int(math.Floor(min(p0.X, p1.X)-pad)), bounds.Min.X)
+	x1 := min(int(math.Ceil(max(p0.X, p1.X)+pad)), bounds.Max.X-1)
+	y0 := max(int(math.Floor(min(p0.Y, p1.Y)-pad)), bounds.Min.Y)
+	y1 := min(int(math.Ceil(max(p0.Y, p1.Y)+pad)), bounds.Max.Y-1)
+
+	d := p1.Sub(p0)
+	len2 := d.Dot(d)
+
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			// distance from the pixel centre to the segment
+			p := primitives.Float2{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			t := 0.0
+			if len2 > 0 {
+				t = math.Max(0, math.Min(1, p.Sub(p0).Dot(d)/len2))
+			}
+			dist := p.Sub(p0.Add(d.Scale(t))).Len()
+
+			coverage := halfWidth + 0.5 - dist
+			if coverage <= 0 {
+				continue
+			}
+			b.blend(x, y, col, math.Min(coverage, 1))
+		}
+	}
+}
+
+// blend composites a premultiplied colour over the pixel at (x, y) scaled by coverage
+func (b *RasterBackend) blend(x, y int, col color.RGBA, coverage float64) {
+	i := b.img.PixOffset(x, y)
+	pix := b.img.Pix[i : i+4 : i+4]
+
+	inv := 1 - float64(col.A)/255*coverage
+	pix[0] = uint8(float64(col.R)*coverage + float64(pix[0])*inv + 0.5)
+	pix[1] = uint8(float64(col.G)*coverage + float64(pix[1])*inv + 0.5)
+	pix[2] = uint8(float64(col.B)*coverage + float64(pix[2])*inv + 0.5)
+	pix[3] = uint8(float64(col.A)*coverage + float64(pix[3])*inv + 0.5)
+}
@@ -0,0 +1,66 @@
+package renderers
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+)
+
+func lit(c color.RGBA) bool {
+	return c.R > 0 || c.G > 0 || c.B > 0
+}
+
+func TestTiledOscilloscopeRasterizes(t *testing.T) {
+	registry := meshes.NewMeshRegistry()
+	dot := registry.Register(meshes.Mesh{
+		Vertices:  []primitives.Float2{{X: 5, Y: 5}},
+		Mode:      meshes.DrawModePoint,
+		Thickness: 2,
+	})
+
+	backend := NewRasterBackend(registry, 40, 20)
+	renderer := GraphRenderer{
+		Root:    Tile(Oscilloscope(dot), 20, 10, 2, 2),
+		Backend: backend,
+	}
+	renderer.Render(&FrameContext{Size: pixel.V(40, 20)})
+	img := backend.Image()
+
+	// one dot per tile, with y flipped into image rows
+	for _, p := range [][2]int{{5, 14}, {25, 14}, {5, 4}, {25, 4}} {
+		if c := img.RGBAAt(p[0], p[1]); !lit(c) {
+			t.Errorf("pixel %v = %v, want the dot drawn", p, c)
+		}
+	}
+	for _, p := range [][2]int{{15, 10}, {0, 0}, {39, 19}, {15, 14}} {
+		if c := img.RGBAAt(p[0], p[1]); lit(c) {
+			t.Errorf("pixel %v = %v, want background", p, c)
+		}
+	}
+}
+
+func TestRasterBackendSegmentsAndColour(t *testing.T) {
+	registry := meshes.NewMeshRegistry()
+	red := primitives.Color{Red: 1}
+	line := registry.Register(meshes.Mesh{
+		Vertices:  []primitives.Float2{{X: 2, Y: 10.5}, {X: 30, Y: 10.5}},
+		Mode:      meshes.DrawModeSegments,
+		Color:     &red,
+		Thickness: 1,
+	})
+
+	backend := NewRasterBackend(registry, 32, 20)
+	renderer := GraphRenderer{Root: Oscilloscope(line), Backend: backend}
+	renderer.Render(&FrameContext{})
+	img := backend.Image()
+
+	if c := img.RGBAAt(16, 9); c.R < 200 || c.G != 0 || c.B != 0 {
+		t.Errorf("on the line = %v, want red", c)
+	}
+	if c := img.RGBAAt(16, 3); lit(c) {
+		t.Errorf("off the line = %v, want background", c)
+	}
+}
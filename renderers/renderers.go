@@ -27,15 +27,19 @@ type RenderFn func(ctx *RenderContext, fc *FrameContext)
 type GraphRenderer struct {
 	Root    RenderFn
 	Backend RenderBackend
+
+	frame uint64
 }
 
 func (r *GraphRenderer) Render(fc *FrameContext) {
+	r.frame++
 	r.Backend.BeginFrame(fc)
 
 	ctx := RenderContext{
 		Backend:   r.Backend,
 		Transform: primitives.IM,
 		Time:      fc.Time,
+		Frame:     r.frame,
 	}
 
 	r.Root(&ctx, fc)
@@ -65,10 +69,34 @@ func (b *IMDrawBackend) BeginFrame(fc *FrameContext) {
 func (b *IMDrawBackend) DrawMesh(meshID meshes.MeshID, transform primitives.Matrix) {
 	mesh := b.registry.Get(meshID)
 	b.im.SetMatrix(pixel.Matrix(transform))
+	b.im.Color = pixel.Alpha(1)
+	if mesh.Color != nil {
+		b.im.Color = pixel.RGB(mesh.Color.Red, mesh.Color.Green, mesh.Color.Blue)
+	}
+	thickness := 1.0
+	if mesh.Thickness > 0 {
+		thickness = mesh.Thickness
+	}
+
+	if mesh.Mode == meshes.DrawModePoint {
+		for _, v := range mesh.Vertices {
+			b.im.Push(pixel.Vec{X: v.X, Y: v.Y})
+			b.im.Circle(thickness/2, 0)
+		}
+		return
+	}
+	if mesh.Mode == meshes.DrawModeSegments {
+		for i := 1; i < len(mesh.Vertices); i += 2 {
+			a, c := mesh.Vertices[i-1], mesh.Vertices[i]
+			b.im.Push(pixel.Vec{X: a.X, Y: a.Y}, pixel.Vec{X: c.X, Y: c.Y})
+			b.im.Line(thickness)
+		}
+		return
+	}
 	for _, v := range mesh.Vertices {
 		b.im.Push(pixel.Vec{X: v.X, Y: v.Y})
 	}
-	b.im.Line(1)
+	b.im.Line(thickness)
 }
 
 func (b *IMDrawBackend) EndFrame(fc *FrameContext) {
@@ -81,12 +109,25 @@ type FrameContext struct {
 	Delta   float64      // frame delta
 	Size    pixel.Vec    // target size in pixels
 	Samples []sources.Sample
+	// Channels holds the samples of every engine channel by name, for renderers drawing several traces
+	Channels map[string][]sources.Sample
 }
 
 type RenderContext struct {
 	Backend   RenderBackend
 	Transform primitives.Matrix
 	Time      float64
+	Frame     uint64 // counts Render calls from 1, so RenderFns can do work once per frame
+}
+
+// Stack draws each RenderFn in order with the same context, later ones on top
+func Stack(fns ...RenderFn) RenderFn {
+	return func(ctx *RenderContext, fc *FrameContext) {
+		for _, fn := range fns {
+			local := *ctx // value copy
+			fn(&local, fc)
+		}
+	}
 }
 
 func Tile(
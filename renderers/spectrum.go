@@ -0,0 +1,249 @@
+package renderers
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+// WindowFunc returns the weight of sample i of n
+type WindowFunc func(i, n int) float64
+
+func HannWindow(i, n int) float64 {
+	return 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+}
+
+func BlackmanWindow(i, n int) float64 {
+	x := 2 * math.Pi * float64(i) / float64(n-1)
+	return 0.42 - 0.5*math.Cos(x) + 0.08*math.Cos(2*x)
+}
+
+// FlatTopWindow trades frequency resolution for accurate peak amplitudes
+func FlatTopWindow(i, n int) float64 {
+	x := 2 * math.Pi * float64(i) / float64(n-1)
+	return 0.21557895 - 0.41663158*math.Cos(x) + 0.277263158*math.Cos(2*x) -
+		0.083578947*math.Cos(3*x) + 0.006947368*math.Cos(4*x)
+}
+
+// SpectrumAnalyzer keeps the latest Size() values of Sample.V and turns them into a windowed magnitude
+// spectrum in dB, with optional exponential averaging and peak hold. A full scale sine reads about 0 dB
+type SpectrumAnalyzer struct {
+	Window       WindowFunc
+	RateHz       float64 // 0 infers the rate from the sample times, again on every Push
+	LogFrequency bool
+	MinFreq      float64 // lower edge of a log axis; 0 uses the first bin
+	MaxFreq      float64 // 0 uses Nyquist
+	MinDB        float64
+	MaxDB        float64
+	Averages     int // number of spectra in the exponential average; 1 disables averaging
+	PeakHold     bool
+	PeakDecay    float64 // dB per second the held peaks fall; 0 holds forever
+
+	size     int       // FFT length, a power of two; the buffers below are sized from it
+	inferred float64   // the RateHz Push last inferred, so a rate set by the caller is left alone
+	history  []float64 // ring buffer of the latest values
+	head     int
+	fft      []complex128
+	power    []float64 // averaged linear power per bin
+	dB       []float64
+	peaks    []float64
+}
+
+func NewSpectrumAnalyzer(size int) *SpectrumAnalyzer {
+	// round up to the next power of two
+	size = 1 << bits.Len(uint(max(size, 2)-1))
+
+	bins := size/2 + 1
+	a := &SpectrumAnalyzer{
+		size:     size,
+		Window:   HannWindow,
+		MinDB:    -100,
+		MaxDB:    0,
+		Averages: 1,
+		history:  make([]float64, size),
+		fft:      make([]complex128, size),
+		power:    make([]float64, bins),
+		dB:       make([]float64, bins),
+		peaks:    make([]float64, bins),
+	}
+	for i := range a.peaks {
+		a.peaks[i] = math.Inf(-1)
+	}
+	return a
+}
+
+// Size is the FFT length, NewSpectrumAnalyzer's size rounded up to a power of two
+func (a *SpectrumAnalyzer) Size() int {
+	return a.size
+}
+
+func (a *SpectrumAnalyzer) Push(samples []sources.Sample) {
+	// keep following the source while the rate is inferred, so a rate change is picked up
+	if (a.RateHz == 0 || a.RateHz == a.inferred) && len(samples) > 1 {
+		if span := samples[len(samples)-1].T - samples[0].T; span > 0 {
+			a.RateHz = float64(len(samples)-1) / span
+			a.inferred = a.RateHz
+		}
+	}
+
+	for _, s := range samples {
+		a.history[a.head] = s.V
+		a.head = (a.head + 1) % a.size
+	}
+}
+
+// Update recomputes the spectrum from the latest values; dt drives the peak decay
+func (a *SpectrumAnalyzer) Update(dt float64) {
+	n := a.size
+	norm := 0.0
+	for i := range n {
+		w := a.Window(i, n)
+		norm += w
+		a.fft[i] = complex(a.history[(a.head+i)%n]*w, 0)
+	}
+	fft(a.fft)
+
+	alpha := 0.0
+	if a.Averages > 1 {
+		alpha = 1 - 1/float64(a.Averages)
+	}
+
+	for k := range a.power {
+		// single sided amplitude, corrected for the window's coherent gain
+		amp := cmplx.Abs(a.fft[k]) / norm
+		if k != 0 && k != n/2 {
+			amp *= 2
+		}
+		a.power[k] = alpha*a.power[k] + (1-alpha)*amp*amp
+		a.dB[k] = 10 * math.Log10(max(a.power[k], 1e-20))
+
+		if a.PeakDecay > 0 {
+			a.peaks[k] -= a.PeakDecay * dt
+		}
+		a.peaks[k] = max(a.peaks[k], a.dB[k])
+	}
+}
+
+func (a *SpectrumAnalyzer) ResetPeaks() {
+	for i := range a.peaks {
+		a.peaks[i] = math.Inf(-1)
+	}
+}
+
+// Magnitudes returns the averaged spectrum in dB, one value per bin from 0 Hz to Nyquist
+func (a *SpectrumAnalyzer) Magnitudes() []float64 {
+	return a.dB
+}
+
+func (a *SpectrumAnalyzer) Peaks() []float64 {
+	return a.peaks
+}
+
+// Frequency of bin k in Hz
+func (a *SpectrumAnalyzer) Frequency(k int) float64 {
+	return float64(k) * a.RateHz / float64(a.size)
+}
+
+// Mesh builds the averaged spectrum as a line across a width x height screen
+func (a *SpectrumAnalyzer) Mesh(width, height float64) meshes.Mesh {
+	return a.mesh(a.dB, width, height, nil)
+}
+
+// PeakMesh builds the held peaks as a second, dimmer line
+func (a *SpectrumAnalyzer) PeakMesh(width, height float64) meshes.Mesh {
+	c := primitives.Color{Red: 1, Green: 0.6, Blue: 0.2}
+	return a.mesh(a.peaks, width, height, &c)
+}
+
+func (a *SpectrumAnalyzer) mesh(values []float64, width, height float64, c *primitives.Color) meshes.Mesh {
+	mesh := meshes.Mesh{Mode: meshes.DrawModeLine, Color: c}
+	if a.RateHz <= 0 {
+		return mesh
+	}
+
+	fMax := a.RateHz / 2
+	if a.MaxFreq > 0 {
+		fMax = min(a.MaxFreq, fMax)
+	}
+	fMin := a.Frequency(1)
+	if a.MinFreq > 0 {
+		fMin = a.MinFreq
+	}
+
+	for k, v := range values {
+		f := a.Frequency(k)
+		if f > fMax || (a.LogFrequency && f < fMin) {
+			continue
+		}
+
+		var x float64
+		if a.LogFrequency {
+			x = math.Log(f/fMin) / math.Log(fMax/fMin) * width
+		} else {
+			x = f / fMax * width
+		}
+
+		level := (v - a.MinDB) / (a.MaxDB - a.MinDB)
+		y := math.Max(0, math.Min(1, level)) * height
+		mesh.Vertices = append(mesh.Vertices, primitives.Float2{X: x, Y: y})
+	}
+	return mesh
+}
+
+// Spectrum is a RenderFn that feeds FrameContext.Samples through the analyzer once per frame and draws
+// the spectrum (and held peaks) in a width x height area, so it can be tiled like Oscilloscope
+func Spectrum(a *SpectrumAnalyzer, registry *meshes.MeshRegistry, width, height float64) RenderFn {
+	traceID := registry.Register(meshes.Mesh{Mode: meshes.DrawModeLine})
+	peakID := registry.Register(meshes.Mesh{Mode: meshes.DrawModeLine})
+
+	var last uint64
+	return func(ctx *RenderContext, fc *FrameContext) {
+		// tiles call this many times per frame, only analyse once
+		if ctx.Frame != last {
+			last = ctx.Frame
+			a.Push(fc.Samples)
+			a.Update(fc.Delta)
+			registry.Update(traceID, a.Mesh(width, height))
+			registry.Update(peakID, a.PeakMesh(width, height))
+		}
+
+		if a.PeakHold {
+			ctx.Backend.DrawMesh(peakID, ctx.Transform)
+		}
+		ctx.Backend.DrawMesh(traceID, ctx.Transform)
+	}
+}
+
+// fft is an in-place iterative radix-2 Cooley-Tukey transform; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	shift := 64 - bits.Len(uint(n-1))
+
+	for i := range n {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if j > i {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range half {
+				even, odd := x[start+k], x[start+k+half]*w
+				x[start+k] = even + odd
+				x[start+k+half] = even - odd
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package renderers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+func TestTiledSpectrumAnalysesOncePerFrame(t *testing.T) {
+	registry := meshes.NewMeshRegistry()
+	analyzer := NewSpectrumAnalyzer(16)
+	renderer := GraphRenderer{
+		Root:    Tile(Spectrum(analyzer, registry, 10, 10), 10, 10, 2, 2),
+		Backend: NewRasterBackend(registry, 20, 20),
+	}
+
+	// the same FrameContext is reused without setting Time, as a render loop may do
+	fc := &FrameContext{Delta: 0.1, Samples: []sources.Sample{{V: 1}}}
+	for range 3 {
+		renderer.Render(fc)
+	}
+
+	if analyzer.head != 3 {
+		t.Errorf("analyzer took %d samples over 3 frames, want 3", analyzer.head)
+	}
+}
+
+func TestSpectrumFindsSine(t *testing.T) {
+	const (
+		size = 1024
+		rate = 1024.0
+		freq = 64.0 // falls on bin 64
+		amp  = 0.5
+	)
+	samples := make([]sources.Sample, size)
+	for i := range samples {
+		t := float64(i) / rate
+		samples[i] = sources.Sample{T: t, V: amp * math.Sin(2*math.Pi*freq*t)}
+	}
+	wantDB := 20 * math.Log10(amp)
+
+	for name, window := range map[string]WindowFunc{
+		"hann":     HannWindow,
+		"blackman": BlackmanWindow,
+		"flat top": FlatTopWindow,
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := NewSpectrumAnalyzer(size)
+			a.Window = window
+			a.Push(samples)
+			a.Update(0)
+
+			mags := a.Magnitudes()
+			peak := 0
+			for k := range mags {
+				if mags[k] > mags[peak] {
+					peak = k
+				}
+			}
+			if peak != 64 || math.Abs(a.Frequency(peak)-freq) > 1e-9 {
+				t.Errorf("peak in bin %d (%v Hz), want bin 64 (%v Hz)", peak, a.Frequency(peak), freq)
+			}
+			if math.Abs(mags[peak]-wantDB) > 0.1 {
+				t.Errorf("peak reads %.2f dB, want %.2f dB", mags[peak], wantDB)
+			}
+		})
+	}
+}
+
+func TestSpectrumFollowsInferredRate(t *testing.T) {
+	at := func(rate float64) []sources.Sample {
+		samples := make([]sources.Sample, 101)
+		for i := range samples {
+			samples[i].T = float64(i) / rate
+		}
+		return samples
+	}
+
+	a := NewSpectrumAnalyzer(64)
+	a.Push(at(1000))
+	a.Push(at(48000))
+	if math.Abs(a.RateHz-48000) > 1e-6 {
+		t.Errorf("inferred rate after a change is %v Hz, want 48000", a.RateHz)
+	}
+
+	a.RateHz = 8000
+	a.Push(at(1000))
+	if a.RateHz != 8000 {
+		t.Errorf("Push replaced a rate set by the caller with %v Hz", a.RateHz)
+	}
+}
@@ -0,0 +1,206 @@
+package renderers
+
+import (
+	"math"
+
+	"github.com/mykeelium/visual-playground/meshes"
+	"github.com/mykeelium/visual-playground/primitives"
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+type TriggerEdge int
+
+const (
+	TriggerRising TriggerEdge = iota
+	TriggerFalling
+)
+
+type TriggerMode int
+
+const (
+	// TriggerAuto sweeps on a trigger, or free runs when none arrives within a sweep
+	TriggerAuto TriggerMode = iota
+	// TriggerNormal only sweeps on a trigger and keeps the last trace otherwise
+	TriggerNormal
+	// TriggerSingle captures one sweep and then waits to be re-armed with Arm
+	TriggerSingle
+)
+
+type Trigger struct {
+	Edge    TriggerEdge
+	Mode    TriggerMode
+	Level   float64 // volts
+	Holdoff float64 // minimum seconds between triggers
+}
+
+// TimeScope turns a stream of samples into triggered Y-T sweeps of Sample.V, like the time base of a
+// real oscilloscope. The trigger is at the left edge of the screen
+type TimeScope struct {
+	TimePerDiv  float64 // seconds per horizontal division
+	VoltsPerDiv float64
+	Offset      float64 // vertical position in volts
+	DivisionsX  int
+	DivisionsY  int
+	Trigger     Trigger
+
+	capturing bool
+	trace     []sources.Sample // sweep in progress, T relative to the trigger
+	display   []sources.Sample // last complete sweep
+	trigT     float64
+	lastTrigT float64
+	lastSweep float64
+	sweeps    int
+	prev      sources.Sample
+	havePrev  bool
+	armed     bool
+}
+
+func NewTimeScope(timePerDiv, voltsPerDiv float64) *TimeScope {
+	return &TimeScope{
+		TimePerDiv:  timePerDiv,
+		VoltsPerDiv: voltsPerDiv,
+		DivisionsX:  10,
+		DivisionsY:  8,
+		lastTrigT:   math.Inf(-1),
+		armed:       true,
+	}
+}
+
+// Sweep is the duration shown across the screen
+func (s *TimeScope) Sweep() float64 {
+	return s.TimePerDiv * float64(s.DivisionsX)
+}
+
+// Arm re-arms a single shot trigger
+func (s *TimeScope) Arm() {
+	s.armed = true
+}
+
+// Waiting reports whether a single shot is armed but has not triggered yet
+func (s *TimeScope) Waiting() bool {
+	return s.Trigger.Mode == TriggerSingle && s.armed
+}
+
+func (s *TimeScope) Push(samples []sources.Sample) {
+	sweep := s.Sweep()
+
+	for _, sample := range samples {
+		if s.capturing {
+			rel := sample.T - s.trigT
+			if rel >= sweep {
+				s.display = append(s.display[:0], s.trace...)
+				s.capturing = false
+				s.lastSweep = sample.T
+				s.sweeps++
+			} else {
+				s.trace = append(s.trace, sources.Sample{T: rel, V: sample.V})
+			}
+		}
+
+		if !s.capturing {
+			if tc, ok := s.crossing(sample); ok {
+				s.start(tc)
+				s.trace = append(s.trace, sources.Sample{T: sample.T - tc, V: sample.V})
+				if s.Trigger.Mode == TriggerSingle {
+					s.armed = false
+				}
+			} else if s.Trigger.Mode == TriggerAuto && sample.T-s.lastSweep >= max(sweep, 0.05) {
+				// nothing triggered in time, free run so the trace never disappears
+				s.start(sample.T)
+				s.trace = append(s.trace, sources.Sample{T: 0, V: sample.V})
+			}
+		}
+
+		s.prev = sample
+		s.havePrev = true
+	}
+}
+
+func (s *TimeScope) start(t float64) {
+	s.capturing = true
+	s.trigT = t
+	s.lastTrigT = t
+	s.trace = s.trace[:0]
+}
+
+// crossing reports whether the signal crossed the trigger level on the configured edge between the
+// previous sample and this one, returning the interpolated crossing time
+func (s *TimeScope) crossing(sample sources.Sample) (float64, bool) {
+	if !s.havePrev || (s.Trigger.Mode == TriggerSingle && !s.armed) {
+		return 0, false
+	}
+
+	a, b, level := s.prev.V, sample.V, s.Trigger.Level
+	var crossed bool
+	switch s.Trigger.Edge {
+	case TriggerRising:
+		crossed = a < level && b >= level
+	case TriggerFalling:
+		crossed = a > level && b <= level
+	}
+	if !crossed {
+		return 0, false
+	}
+
+	tc := s.prev.T + (sample.T-s.prev.T)*(level-a)/(b-a)
+	if tc-s.lastTrigT < s.Trigger.Holdoff {
+		return 0, false
+	}
+	return tc, true
+}
+
+// Sweeps counts completed sweeps, so callers can tell when Trace has changed
+func (s *TimeScope) Sweeps() int {
+	return s.sweeps
+}
+
+// Trace returns the last complete sweep with T relative to the trigger
+func (s *TimeScope) Trace() []sources.Sample {
+	return s.display
+}
+
+func (s *TimeScope) screenY(v float64) float64 {
+	return (v + s.Offset) / s.VoltsPerDiv / (float64(s.DivisionsY) / 2)
+}
+
+// XY maps the last sweep into normalized screen space, x and y in [-1, 1], keeping each sample's time so
+// it can be drawn through a PhosphorBuffer
+func (s *TimeScope) XY() []sources.Sample {
+	sweep := s.Sweep()
+
+	out := make([]sources.Sample, len(s.display))
+	for i, sample := range s.display {
+		out[i] = sources.Sample{
+			T: sample.T,
+			XY: sources.XY{
+				X: 2*sample.T/sweep - 1,
+				Y: s.screenY(sample.V),
+			},
+			V: sample.V,
+		}
+	}
+	return out
+}
+
+// Mesh builds the trace as a line across a width x height screen
+func (s *TimeScope) Mesh(width, height float64) meshes.Mesh {
+	xy := s.XY()
+	pts := make([]primitives.Float2, len(xy))
+	for i, sample := range xy {
+		pts[i] = primitives.Float2{
+			X: (sample.XY.X + 1) / 2 * width,
+			Y: (sample.XY.Y + 1) / 2 * height,
+		}
+	}
+	return meshes.Mesh{Vertices: pts, Mode: meshes.DrawModeLine}
+}
+
+// GraticuleMesh builds the division grid, with small ticks along the centre axes
+func (s *TimeScope) GraticuleMesh(width, height float64) meshes.Mesh {
+	return meshes.BuildGraticuleMesh(width, height, s.DivisionsX, s.DivisionsY)
+}
+
+// OscilloscopeTime draws the graticule then the trace, both built from a TimeScope into the registry
+func OscilloscopeTime(trace, graticule meshes.MeshID) RenderFn {
+	return Stack(Oscilloscope(graticule), Oscilloscope(trace))
+}
@@ -0,0 +1,133 @@
+package renderers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mykeelium/visual-playground/sources"
+)
+
+// sineSamples is duration seconds of a unit sine at freq Hz, sampled at rate from start
+func sineSamples(freq, rate, start, duration float64) []sources.Sample {
+	n := int(math.Round(duration * rate))
+	out := make([]sources.Sample, n)
+	for i := range out {
+		t := start + float64(i)/rate
+		out[i] = sources.Sample{T: t, V: math.Sin(2 * math.Pi * freq * t)}
+	}
+	return out
+}
+
+func flatSamples(rate, start, duration float64) []sources.Sample {
+	out := sineSamples(0, rate, start, duration)
+	for i := range out {
+		out[i].V = 0
+	}
+	return out
+}
+
+func TestTimeScopeTriggersOnEdge(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		edge  TriggerEdge
+		slope float64
+	}{
+		{name: "rising", edge: TriggerRising, slope: 1},
+		{name: "falling", edge: TriggerFalling, slope: -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scope := NewTimeScope(0.0005, 0.25)
+			scope.Trigger = Trigger{Edge: tc.edge, Mode: TriggerNormal, Level: 0.5}
+			scope.Push(sineSamples(100, 10000, 0, 0.05))
+
+			if scope.Sweeps() == 0 {
+				t.Fatal("no sweep triggered")
+			}
+			trace := scope.Trace()
+			if len(trace) < 2 {
+				t.Fatalf("trace has %d samples", len(trace))
+			}
+			// the trace starts at the trigger level, moving in the direction of the edge
+			if trace[0].T < 0 || trace[0].T > 1e-4 || math.Abs(trace[0].V-0.5) > 0.05 {
+				t.Errorf("trace starts at %+v, want the 0.5 V crossing", trace[0])
+			}
+			if got := (trace[1].V - trace[0].V) * tc.slope; got <= 0 {
+				t.Errorf("trace moves from %v to %v, against the %s edge", trace[0].V, trace[1].V, tc.name)
+			}
+		})
+	}
+}
+
+func TestTimeScopeHoldoff(t *testing.T) {
+	// the sine crosses 0.5 V rising once every 10 ms, and a sweep lasts 2 ms
+	samples := sineSamples(100, 10000, 0, 0.1)
+
+	free := NewTimeScope(0.0002, 0.25)
+	free.Trigger = Trigger{Mode: TriggerNormal, Level: 0.5}
+	free.Push(samples)
+	if got := free.Sweeps(); got != 10 {
+		t.Errorf("without holdoff got %d sweeps, want 10", got)
+	}
+
+	held := NewTimeScope(0.0002, 0.25)
+	held.Trigger = Trigger{Mode: TriggerNormal, Level: 0.5, Holdoff: 0.025}
+	held.Push(samples)
+	if got := held.Sweeps(); got != 4 {
+		t.Errorf("with a 25 ms holdoff got %d sweeps, want 4", got)
+	}
+}
+
+func TestTimeScopeAutoFreeRuns(t *testing.T) {
+	scope := NewTimeScope(0.002, 0.25)
+	scope.Trigger = Trigger{Mode: TriggerAuto, Level: 0.5}
+	scope.Push(flatSamples(1000, 0, 0.5))
+
+	if scope.Sweeps() == 0 || len(scope.Trace()) == 0 {
+		t.Errorf("auto mode drew %d sweeps of a flat signal, want it to free run", scope.Sweeps())
+	}
+}
+
+func TestTimeScopeNormalKeepsLastTrace(t *testing.T) {
+	scope := NewTimeScope(0.002, 0.25)
+	scope.Trigger = Trigger{Mode: TriggerNormal, Level: 0.5}
+
+	scope.Push(flatSamples(1000, 0, 0.5))
+	if scope.Sweeps() != 0 {
+		t.Fatalf("normal mode drew %d sweeps without a trigger", scope.Sweeps())
+	}
+
+	scope.Push(sineSamples(10, 1000, 0.5, 0.2))
+	sweeps := scope.Sweeps()
+	if sweeps == 0 {
+		t.Fatal("normal mode did not trigger on the sine")
+	}
+	last := append([]sources.Sample(nil), scope.Trace()...)
+
+	scope.Push(flatSamples(1000, 0.7, 0.5))
+	if scope.Sweeps() != sweeps {
+		t.Errorf("sweeps went from %d to %d without a trigger", sweeps, scope.Sweeps())
+	}
+	trace := scope.Trace()
+	if len(trace) != len(last) || trace[0] != last[0] || trace[len(trace)-1] != last[len(last)-1] {
+		t.Error("the last trace changed without a trigger")
+	}
+}
+
+func TestTimeScopeSingleShot(t *testing.T) {
+	scope := NewTimeScope(0.002, 0.25)
+	scope.Trigger = Trigger{Mode: TriggerSingle, Level: 0.5}
+	if !scope.Waiting() {
+		t.Fatal("a new single shot scope is not armed")
+	}
+
+	scope.Push(sineSamples(10, 1000, 0, 0.5))
+	if scope.Sweeps() != 1 || scope.Waiting() {
+		t.Fatalf("single shot drew %d sweeps (waiting %v), want 1 and disarmed", scope.Sweeps(), scope.Waiting())
+	}
+
+	scope.Arm()
+	scope.Push(sineSamples(10, 1000, 0.5, 0.5))
+	if scope.Sweeps() != 2 {
+		t.Errorf("re-armed single shot drew %d sweeps in total, want 2", scope.Sweeps())
+	}
+}
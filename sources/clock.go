@@ -0,0 +1,63 @@
+package sources
+
+import "math"
+
+// RateSetter is implemented by sources whose sample rate can change while they are running
+type RateSetter interface {
+	SetRate(rate float64)
+}
+
+// clock tracks sample time as an integer index from an epoch so long runs never accumulate drift:
+// t = epochT + index/rate. A rate change starts a new epoch at the next sample, keeping time continuous
+type clock struct {
+	epochT float64
+	index  int64
+	rate   float64
+}
+
+// now is the time of the next sample
+func (c *clock) now() float64 {
+	return c.epochT + float64(c.index)/c.rate
+}
+
+func (c *clock) next() float64 {
+	t := c.now()
+	c.index++
+	return t
+}
+
+func (c *clock) reset() {
+	c.epochT = 0
+	c.index = 0
+}
+
+// seek clamps t to 0, there are no samples before the start
+func (c *clock) seek(t float64) {
+	c.epochT = max(t, 0)
+	c.index = 0
+}
+
+// samples converts a duration to the nearest whole number of samples at the current rate
+func (c *clock) samples(t float64) int {
+	return int(math.Round(t * c.rate))
+}
+
+func (c *clock) setRate(rate float64) {
+	if rate == c.rate || rate <= 0 {
+		return
+	}
+	if c.rate > 0 {
+		// without a rate there is no time yet, a source built with rate 0 starts at its epoch
+		c.epochT = c.now()
+	}
+	c.index = 0
+	c.rate = rate
+}
+
+func setRateAll(inputs []Source, rate float64) {
+	for _, in := range inputs {
+		if rs, ok := in.(RateSetter); ok {
+			rs.SetRate(rate)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package sources
+
+import (
+	"math"
+	"slices"
+)
+
+// Combinators pull samples from their inputs, so an input should only be wired into one place in a
+// graph; use Clone to feed the same signal to several nodes
+
+// Sum adds the V and XY of every input sample by sample
+type Sum struct {
+	Inputs []Source
+
+	pull puller
+}
+
+func NewSum(inputs ...Source) *Sum {
+	return &Sum{Inputs: inputs}
+}
+
+func (s *Sum) Reset() {
+	resetAll(s.Inputs)
+	s.pull.drop()
+}
+
+func (s *Sum) Seek(t float64) {
+	seekAll(s.Inputs, t)
+	s.pull.drop()
+}
+
+func (s *Sum) Clone() Source { return &Sum{Inputs: cloneAll(s.Inputs), pull: s.pull.clone()} }
+
+func (s *Sum) SetRate(rate float64) { setRateAll(s.Inputs, rate) }
+
+func (s *Sum) Update(dt float64) { updateAll(s.Inputs, dt) }
+func (s *Sum) Emit(n int, out []Sample) int {
+	got := s.pull.pull(s.Inputs, n)
+
+	for i := range got {
+		acc := Sample{T: s.pull.bufs[0][i].T}
+		for _, buf := range s.pull.bufs {
+			acc.V += buf[i].V
+			acc.XY.X += buf[i].XY.X
+			acc.XY.Y += buf[i].XY.Y
+		}
+		out[i] = acc
+	}
+	return got
+}
+
+// Product multiplies the V and XY of every input sample by sample, e.g. a ring modulator
+type Product struct {
+	Inputs []Source
+
+	pull puller
+}
+
+func NewProduct(inputs ...Source) *Product {
+	return &Product{Inputs: inputs}
+}
+
+func (p *Product) Reset() {
+	resetAll(p.Inputs)
+	p.pull.drop()
+}
+
+func (p *Product) Seek(t float64) {
+	seekAll(p.Inputs, t)
+	p.pull.drop()
+}
+
+func (p *Product) Clone() Source { return &Product{Inputs: cloneAll(p.Inputs), pull: p.pull.clone()} }
+
+func (p *Product) SetRate(rate float64) { setRateAll(p.Inputs, rate) }
+
+func (p *Product) Update(dt float64) { updateAll(p.Inputs, dt) }
+func (p *Product) Emit(n int, out []Sample) int {
+	got := p.pull.pull(p.Inputs, n)
+
+	for i := range got {
+		acc := Sample{T: p.pull.bufs[0][i].T, V: 1, XY: XY{X: 1, Y: 1}}
+		for _, buf := range p.pull.bufs {
+			acc.V *= buf[i].V
+			acc.XY.X *= buf[i].XY.X
+			acc.XY.Y *= buf[i].XY.Y
+		}
+		out[i] = acc
+	}
+	return got
+}
+
+// AM scales the carrier by (1 + Depth * modulator.V)
+type AM struct {
+	Carrier   Source
+	Modulator Source
+	Depth     float64
+
+	pull puller
+}
+
+func NewAM(carrier, modulator Source, depth float64) *AM {
+	return &AM{Carrier: carrier, Modulator: modulator, Depth: depth}
+}
+
+func (a *AM) Reset() {
+	resetAll([]Source{a.Carrier, a.Modulator})
+	a.pull.drop()
+}
+
+func (a *AM) Seek(t float64) {
+	seekAll([]Source{a.Carrier, a.Modulator}, t)
+	a.pull.drop()
+}
+
+func (a *AM) Clone() Source {
+	return &AM{
+		Carrier:   a.Carrier.Clone(),
+		Modulator: a.Modulator.Clone(),
+		Depth:     a.Depth,
+		pull:      a.pull.clone(),
+	}
+}
+
+func (a *AM) SetRate(rate float64) { setRateAll([]Source{a.Carrier, a.Modulator}, rate) }
+
+func (a *AM) Update(dt float64) { updateAll([]Source{a.Carrier, a.Modulator}, dt) }
+func (a *AM) Emit(n int, out []Sample) int {
+	got := a.pull.pull([]Source{a.Carrier, a.Modulator}, n)
+
+	for i := range got {
+		c := a.pull.bufs[0][i]
+		gain := 1 + a.Depth*a.pull.bufs[1][i].V
+		out[i] = Sample{
+			T:  c.T,
+			V:  c.V * gain,
+			XY: XY{X: c.XY.X * gain, Y: c.XY.Y * gain},
+		}
+	}
+	return got
+}
+
+// PM is an oscillator whose phase is offset by Index * modulator.V radians
+type PM struct {
+	Wave      Waveform
+	Freq      float64 // Hz
+	Amp       float64
+	Index     float64 // radians per unit of modulator
+	Modulator Source
+
+	clock clock
+	buf   []Sample
+}
+
+func NewPM(wave Waveform, freq, amp, index float64, modulator Source, rate float64) *PM {
+	return &PM{
+		Wave:      wave,
+		Freq:      freq,
+		Amp:       amp,
+		Index:     index,
+		Modulator: modulator,
+		clock:     clock{rate: rate},
+	}
+}
+
+func (p *PM) Reset() {
+	p.clock.reset()
+	p.Modulator.Reset()
+}
+
+func (p *PM) Seek(t float64) {
+	p.clock.seek(t)
+	p.Modulator.Seek(t)
+}
+
+func (p *PM) SetRate(rate float64) {
+	p.clock.setRate(rate)
+	setRateAll([]Source{p.Modulator}, rate)
+}
+
+func (p *PM) Clone() Source {
+	c := *p
+	c.Modulator = p.Modulator.Clone()
+	c.buf = nil
+	return &c
+}
+
+func (p *PM) Update(dt float64) { p.Modulator.Update(dt) }
+func (p *PM) Emit(n int, out []Sample) int {
+	p.buf = growSamples(p.buf, n)
+	got := p.Modulator.Emit(n, p.buf)
+
+	for i := range got {
+		t := p.clock.next()
+		phase := p.Freq*t + p.Index*p.buf[i].V/(2*math.Pi)
+		out[i] = Sample{T: t, V: p.Amp * p.Wave(phase)}
+	}
+	return got
+}
+
+// FM is an oscillator whose instantaneous frequency is Freq + Deviation * modulator.V.
+// The phase is integrated sample by sample, so Seek replays the signal from the start
+type FM struct {
+	Wave      Waveform
+	Freq      float64 // Hz
+	Amp       float64
+	Deviation float64 // Hz per unit of modulator
+	Modulator Source
+
+	clock clock
+	phase float64 // cycles
+	buf   []Sample
+}
+
+func NewFM(wave Waveform, freq, amp, deviation float64, modulator Source, rate float64) *FM {
+	return &FM{
+		Wave:      wave,
+		Freq:      freq,
+		Amp:       amp,
+		Deviation: deviation,
+		Modulator: modulator,
+		clock:     clock{rate: rate},
+	}
+}
+
+func (f *FM) Reset() {
+	f.clock.reset()
+	f.phase = 0
+	f.Modulator.Reset()
+}
+
+func (f *FM) Seek(t float64) {
+	f.Reset()
+	if f.clock.rate <= 0 {
+		return // nothing to replay until there is a rate
+	}
+
+	skip := f.clock.samples(max(t, 0))
+	scratch := make([]Sample, min(skip, 4096))
+	for skip > 0 {
+		got := f.Emit(min(skip, len(scratch)), scratch)
+		if got == 0 {
+			break
+		}
+		skip -= got
+	}
+}
+
+func (f *FM) SetRate(rate float64) {
+	f.clock.setRate(rate)
+	setRateAll([]Source{f.Modulator}, rate)
+}
+
+func (f *FM) Clone() Source {
+	c := *f
+	c.Modulator = f.Modulator.Clone()
+	c.buf = nil
+	return &c
+}
+
+func (f *FM) Update(dt float64) { f.Modulator.Update(dt) }
+
+// Emit produces nothing until the FM has a rate, since the phase steps by 1/rate
+func (f *FM) Emit(n int, out []Sample) int {
+	if f.clock.rate <= 0 {
+		return 0
+	}
+	f.buf = growSamples(f.buf, n)
+	got := f.Modulator.Emit(n, f.buf)
+
+	for i := range got {
+		t := f.clock.next()
+		out[i] = Sample{T: t, V: f.Amp * f.Wave(f.phase)}
+
+		f.phase += (f.Freq + f.Deviation*f.buf[i].V) / f.clock.rate
+		f.phase -= math.Floor(f.phase)
+	}
+	return got
+}
+
+// XYCombiner draws one scalar source against another: X.V drives the x axis and Y.V the y axis
+type XYCombiner struct {
+	X Source
+	Y Source
+
+	pull puller
+}
+
+func NewXY(x, y Source) *XYCombiner {
+	return &XYCombiner{X: x, Y: y}
+}
+
+func (c *XYCombiner) Reset() {
+	resetAll([]Source{c.X, c.Y})
+	c.pull.drop()
+}
+
+func (c *XYCombiner) Seek(t float64) {
+	seekAll([]Source{c.X, c.Y}, t)
+	c.pull.drop()
+}
+
+func (c *XYCombiner) Clone() Source {
+	return &XYCombiner{X: c.X.Clone(), Y: c.Y.Clone(), pull: c.pull.clone()}
+}
+
+func (c *XYCombiner) SetRate(rate float64) { setRateAll([]Source{c.X, c.Y}, rate) }
+
+func (c *XYCombiner) Update(dt float64) { updateAll([]Source{c.X, c.Y}, dt) }
+func (c *XYCombiner) Emit(n int, out []Sample) int {
+	got := c.pull.pull([]Source{c.X, c.Y}, n)
+
+	for i := range got {
+		x, y := c.pull.bufs[0][i].V, c.pull.bufs[1][i].V
+		out[i] = Sample{
+			T:  c.pull.bufs[0][i].T,
+			XY: XY{X: x, Y: y},
+			V:  (x + y) / 2,
+		}
+	}
+	return got
+}
+
+func growSamples(buf []Sample, n int) []Sample {
+	if cap(buf) < n {
+		return make([]Sample, n)
+	}
+	return buf[:n]
+}
+
+// puller pulls samples from several inputs in step. An input that produces more than the shortest keeps
+// the extra back, to be used first on the next pull, so the inputs never drift apart
+type puller struct {
+	bufs    [][]Sample
+	pending [][]Sample
+	counts  []int
+}
+
+// pull emits n samples from every input into its own buffer and returns how many all of them produced
+func (p *puller) pull(inputs []Source, n int) int {
+	if len(inputs) == 0 {
+		return 0
+	}
+	if len(p.bufs) != len(inputs) {
+		p.bufs = make([][]Sample, len(inputs))
+		p.pending = make([][]Sample, len(inputs))
+		p.counts = make([]int, len(inputs))
+	}
+
+	got := n
+	counts := p.counts
+	for i, in := range inputs {
+		p.bufs[i] = growSamples(p.bufs[i], n)
+		held := copy(p.bufs[i], p.pending[i])
+		counts[i] = held + in.Emit(n-held, p.bufs[i][held:])
+		got = min(got, counts[i])
+	}
+
+	for i := range inputs {
+		held := min(len(p.pending[i]), n)
+		rest := p.pending[i][held:]
+		if counts[i] == got && len(rest) == 0 {
+			p.pending[i] = p.pending[i][:0]
+			continue
+		}
+		p.pending[i] = append(slices.Clone(p.bufs[i][got:counts[i]]), rest...)
+	}
+	return got
+}
+
+// clone copies the held back samples, so a clone stays in step with its cloned inputs
+func (p *puller) clone() puller {
+	c := puller{
+		bufs:    make([][]Sample, len(p.bufs)),
+		pending: make([][]Sample, len(p.pending)),
+		counts:  make([]int, len(p.counts)),
+	}
+	for i, held := range p.pending {
+		c.pending[i] = slices.Clone(held)
+	}
+	return c
+}
+
+// drop forgets held back samples, when the inputs are rewound or moved
+func (p *puller) drop() {
+	for i := range p.pending {
+		p.pending[i] = p.pending[i][:0]
+	}
+}
+
+func resetAll(inputs []Source) {
+	for _, in := range inputs {
+		in.Reset()
+	}
+}
+
+func seekAll(inputs []Source, t float64) {
+	for _, in := range inputs {
+		in.Seek(t)
+	}
+}
+
+func updateAll(inputs []Source, dt float64) {
+	for _, in := range inputs {
+		in.Update(dt)
+	}
+}
+
+func cloneAll(inputs []Source) []Source {
+	clones := make([]Source, len(inputs))
+	for i, in := range inputs {
+		clones[i] = in.Clone()
+	}
+	return clones
+}
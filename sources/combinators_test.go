@@ -0,0 +1,118 @@
+package sources
+
+import (
+	"math"
+	"testing"
+)
+
+// counter emits its sample index as V, at most limit samples per Emit when limit is set
+type counter struct {
+	next  int
+	limit int
+}
+
+func (c *counter) Reset()            { c.next = 0 }
+func (c *counter) Seek(t float64)    { c.next = int(t) }
+func (c *counter) Clone() Source     { clone := *c; return &clone }
+func (c *counter) Update(dt float64) {}
+
+func (c *counter) Emit(n int, out []Sample) int {
+	if c.limit > 0 {
+		n = min(n, c.limit)
+	}
+	for i := range n {
+		out[i] = Sample{T: float64(c.next), V: float64(c.next)}
+		c.next++
+	}
+	return n
+}
+
+func TestSumStaysAlignedWithShortInputs(t *testing.T) {
+	sum := NewSum(&counter{}, &counter{limit: 3})
+	out := make([]Sample, 8)
+
+	index := 0
+	for range 10 {
+		got := sum.Emit(len(out), out)
+		for i := range got {
+			// both inputs are at the same index, so the sum is always twice it
+			if want := float64(2 * index); out[i].V != want {
+				t.Fatalf("sample %d = %v, want %v", index, out[i].V, want)
+			}
+			index++
+		}
+	}
+	if index != 30 {
+		t.Errorf("emitted %d samples, want 30", index)
+	}
+
+	sum.Reset()
+	if got := sum.Emit(2, out); got != 2 || out[0].V != 0 || out[1].V != 2 {
+		t.Errorf("after Reset got %d samples %v, want to start again from 0", got, out[:got])
+	}
+}
+
+func TestXYStaysAlignedWithShortInputs(t *testing.T) {
+	xy := NewXY(&counter{limit: 5}, &counter{limit: 2})
+	out := make([]Sample, 6)
+
+	index := 0
+	for range 10 {
+		got := xy.Emit(len(out), out)
+		for i := range got {
+			if out[i].XY.X != out[i].XY.Y || out[i].XY.X != float64(index) {
+				t.Fatalf("sample %d = %v, want x and y both %d", index, out[i].XY, index)
+			}
+			index++
+		}
+	}
+}
+
+func TestCloneKeepsHeldBackSamples(t *testing.T) {
+	combinators := map[string]Source{
+		"sum":     NewSum(&counter{}, &counter{limit: 3}),
+		"product": NewProduct(&counter{}, &counter{limit: 3}),
+		"am":      NewAM(&counter{}, &counter{limit: 3}, 0.5),
+		"xy":      NewXY(&counter{limit: 2}, &counter{}),
+	}
+
+	for name, src := range combinators {
+		t.Run(name, func(t *testing.T) {
+			out := make([]Sample, 8)
+			src.Emit(len(out), out) // leaves samples held back from the unlimited input
+
+			clone := src.Clone()
+			want, got := make([]Sample, 8), make([]Sample, 8)
+			for range 3 {
+				n := src.Emit(len(want), want)
+				if m := clone.Emit(len(got), got); m != n {
+					t.Fatalf("clone emitted %d samples, original %d", m, n)
+				}
+				for i := range n {
+					if got[i] != want[i] {
+						t.Fatalf("clone sample %d = %+v, original %+v", i, got[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFMWaitsForARate(t *testing.T) {
+	fm := NewFM(SineWave, 5, 1, 2, NewSine(2, 1, 0), 0)
+	out := make([]Sample, 4)
+	if got := fm.Emit(len(out), out); got != 0 {
+		t.Fatalf("FM without a rate emitted %d samples %v, want none", got, out[:got])
+	}
+	fm.Seek(1)
+
+	fm.SetRate(100)
+	if got := fm.Emit(len(out), out); got != len(out) {
+		t.Fatalf("emitted %d samples once the rate was set, want %d", got, len(out))
+	}
+	for i, s := range out {
+		if math.IsNaN(s.T) || math.IsNaN(s.V) || math.IsInf(s.V, 0) {
+			t.Errorf("sample %d = %+v, want finite", i, s)
+		}
+	}
+}
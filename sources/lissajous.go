@@ -8,36 +8,45 @@ type ScopeParams struct {
 	Fx, Fy float64
 	Phase  float64
 	Gain   float64
-	Decay  float64
+	Decay  float64 // phosphor persistence in seconds
 }
 
 type Lissajous struct {
-	Params  *ScopeParams
-	sampleT float64
-	rate    float64
+	Params *ScopeParams
+	clock  clock
 }
 
 func NewLissajous(params *ScopeParams, rate float64) *Lissajous {
 	return &Lissajous{
 		Params: params,
-		rate:   rate,
+		clock:  clock{rate: rate},
 	}
 }
 
+func (l *Lissajous) Reset()               { l.clock.reset() }
+func (l *Lissajous) Seek(t float64)       { l.clock.seek(t) }
+func (l *Lissajous) SetRate(rate float64) { l.clock.setRate(rate) }
+
+// Clone copies the playback position; the clone shares Params so live tweaks apply to both
+func (l *Lissajous) Clone() Source {
+	c := *l
+	return &c
+}
+
 func (l *Lissajous) Update(dt float64) {}
 func (l *Lissajous) Emit(n int, out []Sample) int {
 	p := l.Params
-	dt := 1.0 / l.rate
 	for i := range n {
-		t := l.sampleT
+		t := l.clock.next()
+		x := math.Sin(2*math.Pi*p.Fx*t + p.Phase)
 		out[i] = Sample{
 			T: t,
 			XY: XY{
-				X: math.Sin(2*math.Pi*p.Fx*t + p.Phase),
+				X: x,
 				Y: math.Sin(2 * math.Pi * p.Fy * t),
 			},
+			V: x, // the x channel doubles as the time-domain signal
 		}
-		l.sampleT += dt
 	}
 	return n
 }
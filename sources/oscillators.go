@@ -0,0 +1,153 @@
+package sources
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Waveform maps a phase in cycles (one period per unit) to a value in [-1, 1]
+type Waveform func(phase float64) float64
+
+func SineWave(phase float64) float64 {
+	return math.Sin(2 * math.Pi * phase)
+}
+
+func SquareWave(phase float64) float64 {
+	if cycleFrac(phase) < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func SawWave(phase float64) float64 {
+	return 2*cycleFrac(phase) - 1
+}
+
+// TriangleWave starts at 0 and rises, matching the phase of SineWave
+func TriangleWave(phase float64) float64 {
+	return 1 - 4*math.Abs(cycleFrac(phase+0.25)-0.5)
+}
+
+func cycleFrac(phase float64) float64 {
+	return phase - math.Floor(phase)
+}
+
+// Oscillator is a scalar source: Offset + Amp * Wave(Freq*t + Phase/2π).
+// Scalar sources only fill Sample.T and Sample.V; use XY to turn two of them into a figure
+type Oscillator struct {
+	Wave   Waveform
+	Freq   float64 // Hz
+	Amp    float64
+	Phase  float64 // radians
+	Offset float64
+
+	clock clock
+}
+
+func NewOscillator(wave Waveform, freq, amp, rate float64) *Oscillator {
+	return &Oscillator{
+		Wave:  wave,
+		Freq:  freq,
+		Amp:   amp,
+		clock: clock{rate: rate},
+	}
+}
+
+func NewSine(freq, amp, rate float64) *Oscillator {
+	return NewOscillator(SineWave, freq, amp, rate)
+}
+
+func NewSquare(freq, amp, rate float64) *Oscillator {
+	return NewOscillator(SquareWave, freq, amp, rate)
+}
+
+func NewSaw(freq, amp, rate float64) *Oscillator {
+	return NewOscillator(SawWave, freq, amp, rate)
+}
+
+func NewTriangle(freq, amp, rate float64) *Oscillator {
+	return NewOscillator(TriangleWave, freq, amp, rate)
+}
+
+func (o *Oscillator) Reset()               { o.clock.reset() }
+func (o *Oscillator) Seek(t float64)       { o.clock.seek(t) }
+func (o *Oscillator) SetRate(rate float64) { o.clock.setRate(rate) }
+
+func (o *Oscillator) Clone() Source {
+	c := *o
+	return &c
+}
+
+func (o *Oscillator) Update(dt float64) {}
+func (o *Oscillator) Emit(n int, out []Sample) int {
+	for i := range n {
+		t := o.clock.next()
+		out[i] = Sample{
+			T: t,
+			V: o.Offset + o.Amp*o.Wave(o.Freq*t+o.Phase/(2*math.Pi)),
+		}
+	}
+	return n
+}
+
+// Noise is a scalar source of uniform white noise in [-Amp, Amp]. It is deterministic for a given seed,
+// so Reset and Seek replay the same sequence
+type Noise struct {
+	Amp  float64
+	Seed int64
+
+	clock clock
+	rng   *rand.Rand
+	drawn int
+}
+
+func NewNoise(amp float64, seed int64, rate float64) *Noise {
+	return &Noise{
+		Amp:   amp,
+		Seed:  seed,
+		clock: clock{rate: rate},
+		rng:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *Noise) Reset() {
+	s.clock.reset()
+	s.rng = rand.New(rand.NewSource(s.Seed))
+	s.drawn = 0
+}
+
+// Seek replays the generator up to t so the sequence matches a straight run
+func (s *Noise) Seek(t float64) {
+	s.Reset()
+	s.skip(s.clock.samples(max(t, 0)))
+	s.clock.seek(t)
+}
+
+func (s *Noise) SetRate(rate float64) { s.clock.setRate(rate) }
+
+func (s *Noise) Clone() Source {
+	c := *s
+	c.rng = rand.New(rand.NewSource(s.Seed))
+	c.drawn = 0
+	c.skip(s.drawn)
+	return &c
+}
+
+func (s *Noise) skip(n int) {
+	for range n {
+		s.rng.Float64()
+	}
+	s.drawn += n
+}
+
+func (s *Noise) Update(dt float64) {}
+func (s *Noise) Emit(n int, out []Sample) int {
+	for i := range n {
+		out[i] = Sample{
+			T: s.clock.next(),
+			V: s.Amp * (2*s.rng.Float64() - 1),
+		}
+	}
+	s.drawn += n
+	return n
+}
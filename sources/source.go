@@ -9,7 +9,12 @@ type Sample struct {
 }
 
 type Source interface {
-	// 	Reset()
+	// Reset rewinds the source to t = 0
+	Reset()
+	// Seek moves the source so the next emitted sample is at time t (seconds)
+	Seek(t float64)
+	// Clone returns an independent copy of the source at its current position
+	Clone() Source
 	Update(dt float64)
 	Emit(n int, out []Sample) int
 }
@@ -0,0 +1,60 @@
+package sources
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSeekBeforeStartClampsToZero(t *testing.T) {
+	const rate = 100
+
+	wav := &WAVSource{
+		frames:   []XY{{X: 0.5}, {X: 0.25}, {X: -0.5}},
+		fileRate: rate,
+		clock:    clock{rate: rate},
+	}
+	srcs := map[string]Source{
+		"sine":      NewSine(5, 1, rate),
+		"noise":     NewNoise(1, 7, rate),
+		"lissajous": NewLissajous(&ScopeParams{Fx: 3, Fy: 2}, rate),
+		"pm":        NewPM(SineWave, 5, 1, 1, NewSine(2, 1, rate), rate),
+		"fm":        NewFM(SineWave, 5, 1, 2, NewSine(2, 1, rate), rate),
+		"sum":       NewSum(NewSine(5, 1, rate), NewSaw(3, 1, rate)),
+		"wav":       wav,
+	}
+
+	for name, src := range srcs {
+		t.Run(name, func(t *testing.T) {
+			want := make([]Sample, 3)
+			src.Reset()
+			src.Emit(len(want), want)
+
+			for _, at := range []float64{-0.5, -1} {
+				got := make([]Sample, 3)
+				src.Seek(at)
+				if n := src.Emit(len(got), got); n != len(got) {
+					t.Fatalf("Seek(%v) then Emit gave %d samples, want %d", at, n, len(got))
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Errorf("Seek(%v) sample %d = %+v, want %+v from the start", at, i, got[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestZeroRateTakesTheFirstRateSet(t *testing.T) {
+	src := NewSine(100, 1, 0)
+	src.SetRate(1000)
+
+	out := make([]Sample, 4)
+	src.Emit(len(out), out)
+	for i, s := range out {
+		wantT := float64(i) / 1000
+		if math.Abs(s.T-wantT) > 1e-12 || math.Abs(s.V-math.Sin(2*math.Pi*100*wantT)) > 1e-9 {
+			t.Errorf("sample %d = %+v, want T %v on the sine", i, s, wantT)
+		}
+	}
+}
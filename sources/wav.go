@@ -0,0 +1,358 @@
+package sources
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	// wavMaxFormat is the longest fmt chunk body, WAVE_FORMAT_EXTENSIBLE
+	wavMaxFormat = 40
+)
+
+var ErrNotWAV = errors.New("sources: not a RIFF/WAVE stream")
+
+// WAVSource plays back a decoded WAV file as samples, left channel = X and right channel = Y.
+// Frames are linearly resampled from the file rate to the rate the source was created with
+type WAVSource struct {
+	Loop bool
+
+	frames   []XY
+	fileRate float64
+	clock    clock
+}
+
+func LoadWAV(path string, rate float64) (*WAVSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return DecodeWAV(bufio.NewReader(f), rate)
+}
+
+// DecodeWAV reads 8/16/24/32-bit PCM or 32/64-bit float WAV data. Mono files drive both X and Y
+func DecodeWAV(r io.Reader, rate float64) (*WAVSource, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+
+	var (
+		format     uint16
+		channels   int
+		fileRate   int
+		bits       int
+		blockAlign int
+		haveFormat bool
+	)
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("sources: WAV has no data chunk")
+			}
+			return nil, err
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("sources: WAV fmt chunk too short (%d bytes)", size)
+			}
+			// the size comes from the file, so only the fields we read are kept and any extension skipped
+			body := make([]byte, min(size, wavMaxFormat))
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, err
+			}
+			if _, err := io.CopyN(io.Discard, r, size-int64(len(body))); err != nil {
+				return nil, err
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			fileRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			blockAlign = int(binary.LittleEndian.Uint16(body[12:14]))
+			bits = int(binary.LittleEndian.Uint16(body[14:16]))
+			if format == wavFormatExtensible && size >= 26 {
+				// the sub format GUID starts with the real format tag
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			haveFormat = true
+
+		case "data":
+			if !haveFormat {
+				return nil, errors.New("sources: WAV data chunk before fmt chunk")
+			}
+			decode, err := wavDecoder(format, bits)
+			if err != nil {
+				return nil, err
+			}
+			if channels < 1 || blockAlign < channels*bits/8 || fileRate <= 0 {
+				return nil, fmt.Errorf("sources: invalid WAV layout (channels=%d rate=%d align=%d)", channels, fileRate, blockAlign)
+			}
+
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, err
+			}
+
+			width := bits / 8
+			frames := make([]XY, len(data)/blockAlign)
+			for i := range frames {
+				frame := data[i*blockAlign:]
+				x := decode(frame[:width])
+				y := x
+				if channels > 1 {
+					y = decode(frame[width : 2*width])
+				}
+				frames[i] = XY{X: x, Y: y}
+			}
+
+			return &WAVSource{
+				frames:   frames,
+				fileRate: float64(fileRate),
+				clock:    clock{rate: rate},
+			}, nil
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return nil, err
+			}
+		}
+
+		// chunks are padded to an even length
+		if size%2 == 1 {
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func wavDecoder(format uint16, bits int) (func([]byte) float64, error) {
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }, nil
+	case format == wavFormatPCM && bits == 16:
+		return func(b []byte) float64 {
+			return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		}, nil
+	case format == wavFormatPCM && bits == 24:
+		return func(b []byte) float64 {
+			v := int32(b[0])<<8 | int32(b[1])<<16 | int32(b[2])<<24
+			return float64(v>>8) / (1 << 23)
+		}, nil
+	case format == wavFormatPCM && bits == 32:
+		return func(b []byte) float64 {
+			return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}, nil
+	case format == wavFormatFloat && bits == 32:
+		return func(b []byte) float64 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}, nil
+	case format == wavFormatFloat && bits == 64:
+		return func(b []byte) float64 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}, nil
+	}
+	return nil, fmt.Errorf("sources: unsupported WAV encoding (format=%d bits=%d)", format, bits)
+}
+
+// Duration is the length of the decoded audio in seconds
+func (w *WAVSource) Duration() float64 {
+	return float64(len(w.frames)) / w.fileRate
+}
+
+func (w *WAVSource) Reset()               { w.clock.reset() }
+func (w *WAVSource) Seek(t float64)       { w.clock.seek(t) }
+func (w *WAVSource) SetRate(rate float64) { w.clock.setRate(rate) }
+
+// Clone copies the playback position; decoded frames are shared since they are never modified
+func (w *WAVSource) Clone() Source {
+	c := *w
+	return &c
+}
+
+func (w *WAVSource) Update(dt float64) {}
+
+// Emit returns fewer than n samples once the end of the file is reached, unless Loop is set
+func (w *WAVSource) Emit(n int, out []Sample) int {
+	if len(w.frames) == 0 {
+		return 0
+	}
+
+	duration := w.Duration()
+	for i := range n {
+		t := w.clock.now()
+		if t >= duration {
+			if !w.Loop {
+				return i
+			}
+			t = math.Mod(t, duration)
+			w.clock.seek(t)
+		}
+		w.clock.next()
+
+		xy := w.at(t * w.fileRate)
+		out[i] = Sample{
+			T:  t,
+			XY: xy,
+			V:  (xy.X + xy.Y) / 2,
+		}
+	}
+	return n
+}
+
+// at linearly interpolates between the frames around pos (in file frames)
+func (w *WAVSource) at(pos float64) XY {
+	i := int(pos)
+	if i >= len(w.frames)-1 {
+		return w.frames[len(w.frames)-1]
+	}
+	frac := pos - float64(i)
+	a, b := w.frames[i], w.frames[i+1]
+	return XY{
+		X: a.X + (b.X-a.X)*frac,
+		Y: a.Y + (b.Y-a.Y)*frac,
+	}
+}
+
+// WAVSink writes XY samples to a stereo WAV stream, left = X and right = Y.
+// The header sizes are patched in Close, so the writer must be seekable
+type WAVSink struct {
+	w      io.WriteSeeker
+	rate   int
+	bits   int
+	frames int
+	buf    []byte
+}
+
+// NewWAVSink writes 16-bit PCM when bits is 16 and 32-bit float when bits is 32
+func NewWAVSink(w io.WriteSeeker, rate int, bits int) (*WAVSink, error) {
+	if bits != 16 && bits != 32 {
+		return nil, fmt.Errorf("sources: unsupported WAV sink bit depth %d", bits)
+	}
+
+	s := &WAVSink{w: w, rate: rate, bits: bits}
+	if err := s.writeHeader(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *WAVSink) Write(samples []Sample) error {
+	width := s.bits / 8
+	s.buf = s.buf[:0]
+	for _, sample := range samples {
+		for _, v := range [2]float64{sample.XY.X, sample.XY.Y} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				v = 0 // a broken sample is silence, not whatever NaN converts to
+			}
+			v = max(-1, min(1, v))
+			if s.bits == 16 {
+				s.buf = binary.LittleEndian.AppendUint16(s.buf, uint16(int16(math.Round(v*math.MaxInt16))))
+			} else {
+				s.buf = binary.LittleEndian.AppendUint32(s.buf, math.Float32bits(float32(v)))
+			}
+		}
+	}
+
+	if _, err := s.w.Write(s.buf); err != nil {
+		return err
+	}
+	s.frames += len(s.buf) / (2 * width)
+	return nil
+}
+
+// Close finalizes the header; it does not close the underlying writer
+func (s *WAVSink) Close() error {
+	if _, err := s.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+	_, err := s.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (s *WAVSink) writeHeader() error {
+	format := uint16(wavFormatPCM)
+	if s.bits == 32 {
+		format = wavFormatFloat
+	}
+	blockAlign := 2 * s.bits / 8
+	dataSize := uint32(s.frames * blockAlign)
+
+	h := make([]byte, 0, 44)
+	h = append(h, "RIFF"...)
+	h = binary.LittleEndian.AppendUint32(h, 36+dataSize)
+	h = append(h, "WAVE"...)
+	h = append(h, "fmt "...)
+	h = binary.LittleEndian.AppendUint32(h, 16)
+	h = binary.LittleEndian.AppendUint16(h, format)
+	h = binary.LittleEndian.AppendUint16(h, 2)
+	h = binary.LittleEndian.AppendUint32(h, uint32(s.rate))
+	h = binary.LittleEndian.AppendUint32(h, uint32(s.rate*blockAlign))
+	h = binary.LittleEndian.AppendUint16(h, uint16(blockAlign))
+	h = binary.LittleEndian.AppendUint16(h, uint16(s.bits))
+	h = append(h, "data"...)
+	h = binary.LittleEndian.AppendUint32(h, dataSize)
+
+	_, err := s.w.Write(h)
+	return err
+}
+
+// ExportWAV renders duration seconds of src to a stereo WAV file. src is switched to the given rate first
+func ExportWAV(path string, src Source, rate int, bits int, duration float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := exportWAV(f, src, rate, bits, duration); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func exportWAV(w io.WriteSeeker, src Source, rate int, bits int, duration float64) error {
+	sink, err := NewWAVSink(w, rate, bits)
+	if err != nil {
+		return err
+	}
+
+	setRateAll([]Source{src}, float64(rate))
+
+	buf := make([]Sample, 4096)
+	remaining := int(math.Round(duration * float64(rate)))
+	for remaining > 0 {
+		want := min(remaining, len(buf))
+		got := src.Emit(want, buf)
+		if err := sink.Write(buf[:got]); err != nil {
+			return err
+		}
+		if got < want {
+			break
+		}
+		remaining -= got
+	}
+
+	return sink.Close()
+}
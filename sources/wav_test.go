@@ -0,0 +1,227 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// seekBuffer is an in-memory io.WriteSeeker for WAVSink
+type seekBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (b *seekBuffer) Write(p []byte) (int, error) {
+	if end := b.pos + len(p); end > len(b.data) {
+		b.data = append(b.data, make([]byte, end-len(b.data))...)
+	}
+	b.pos += copy(b.data[b.pos:], p)
+	return len(p), nil
+}
+
+func (b *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		b.pos = int(offset)
+	case io.SeekCurrent:
+		b.pos += int(offset)
+	case io.SeekEnd:
+		b.pos = len(b.data) + int(offset)
+	}
+	return int64(b.pos), nil
+}
+
+// wavChunk encodes a chunk, padded to an even length
+func wavChunk(id string, body []byte) []byte {
+	c := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	c = append(c, body...)
+	if len(body)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+func wavFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func wavFormat(format uint16, channels, rate, bits int) []byte {
+	align := channels * bits / 8
+	b := binary.LittleEndian.AppendUint16(nil, format)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate*align))
+	b = binary.LittleEndian.AppendUint16(b, uint16(align))
+	return binary.LittleEndian.AppendUint16(b, uint16(bits))
+}
+
+// decodeAll decodes a WAV at its own rate and returns every frame
+func decodeAll(t *testing.T, data []byte, rate float64) []XY {
+	t.Helper()
+	src, err := DecodeWAV(bytes.NewReader(data), rate)
+	if err != nil {
+		t.Fatalf("DecodeWAV: %v", err)
+	}
+	out := make([]Sample, len(src.frames)+1)
+	n := src.Emit(len(out), out)
+	frames := make([]XY, n)
+	for i := range n {
+		frames[i] = out[i].XY
+	}
+	return frames
+}
+
+func checkFrames(t *testing.T, got, want []XY, tol float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d frames, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i].X-want[i].X) > tol || math.Abs(got[i].Y-want[i].Y) > tol {
+			t.Errorf("frame %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWAVSinkRoundTrip(t *testing.T) {
+	in := []XY{
+		{X: 0, Y: 0}, {X: 0.5, Y: -0.25}, {X: 1, Y: -1}, {X: -0.75, Y: 0.125},
+		{X: math.NaN(), Y: math.Inf(1)}, {X: math.Inf(-1), Y: 2},
+	}
+	// non-finite values are written as silence, and the rest clamped to full scale
+	want := []XY{
+		{X: 0, Y: 0}, {X: 0.5, Y: -0.25}, {X: 1, Y: -1}, {X: -0.75, Y: 0.125},
+		{X: 0, Y: 0}, {X: 0, Y: 1},
+	}
+	samples := make([]Sample, len(in))
+	for i, xy := range in {
+		samples[i] = Sample{XY: xy}
+	}
+
+	for _, tc := range []struct {
+		bits int
+		tol  float64
+	}{
+		{bits: 16, tol: 1.0 / math.MaxInt16},
+		{bits: 32, tol: 1e-7},
+	} {
+		var buf seekBuffer
+		sink, err := NewWAVSink(&buf, 100, tc.bits)
+		if err != nil {
+			t.Fatalf("%d-bit: NewWAVSink: %v", tc.bits, err)
+		}
+		if err := sink.Write(samples); err != nil {
+			t.Fatalf("%d-bit: Write: %v", tc.bits, err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatalf("%d-bit: Close: %v", tc.bits, err)
+		}
+
+		checkFrames(t, decodeAll(t, buf.data, 100), want, tc.tol)
+	}
+}
+
+func TestDecodeWAVPCMDepths(t *testing.T) {
+	// mono files drive both X and Y
+	mono := func(vs ...float64) []XY {
+		xys := make([]XY, len(vs))
+		for i, v := range vs {
+			xys[i] = XY{X: v, Y: v}
+		}
+		return xys
+	}
+
+	eight := wavFile(
+		wavChunk("fmt ", wavFormat(wavFormatPCM, 1, 100, 8)),
+		wavChunk("data", []byte{0, 128, 192}),
+	)
+	checkFrames(t, decodeAll(t, eight, 100), mono(-1, 0, 0.5), 1e-9)
+
+	twentyFour := wavFile(
+		wavChunk("fmt ", wavFormat(wavFormatPCM, 1, 100, 24)),
+		wavChunk("data", []byte{0x00, 0x00, 0x80, 0x00, 0x00, 0x40, 0x00, 0x00, 0xC0}),
+	)
+	checkFrames(t, decodeAll(t, twentyFour, 100), mono(-1, 0.5, -0.5), 1e-9)
+}
+
+func TestDecodeWAVExtensibleFormat(t *testing.T) {
+	// WAVE_FORMAT_EXTENSIBLE: cbSize, valid bits, channel mask, then the sub format GUID
+	fmtBody := wavFormat(wavFormatExtensible, 2, 100, 32)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 22)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, 32)
+	fmtBody = binary.LittleEndian.AppendUint32(fmtBody, 3)
+	fmtBody = binary.LittleEndian.AppendUint16(fmtBody, wavFormatFloat)
+	fmtBody = append(fmtBody, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+
+	want := []XY{{X: 0.25, Y: -0.5}, {X: -1, Y: 1}}
+	var data []byte
+	for _, xy := range want {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(xy.X)))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(xy.Y)))
+	}
+
+	file := wavFile(wavChunk("fmt ", fmtBody), wavChunk("data", data))
+	checkFrames(t, decodeAll(t, file, 100), want, 1e-9)
+}
+
+func TestDecodeWAVSkipsPaddedChunks(t *testing.T) {
+	file := wavFile(
+		wavChunk("LIST", []byte("odd")),
+		wavChunk("fmt ", wavFormat(wavFormatPCM, 1, 100, 8)),
+		wavChunk("junk", []byte{1}),
+		wavChunk("data", []byte{128, 0}),
+	)
+	checkFrames(t, decodeAll(t, file, 100), []XY{{}, {X: -1, Y: -1}}, 1e-9)
+}
+
+func TestDecodeWAVRejectsBadFmt(t *testing.T) {
+	short := wavFile(wavChunk("fmt ", wavFormat(wavFormatPCM, 1, 100, 16)[:12]))
+	if _, err := DecodeWAV(bytes.NewReader(short), 100); err == nil {
+		t.Error("fmt chunk shorter than 16 bytes decoded without error")
+	}
+
+	// the chunk claims 16 bytes but the stream ends after 10
+	full := wavFile(wavChunk("fmt ", wavFormat(wavFormatPCM, 1, 100, 16)))
+	truncated := full[:len(full)-6]
+	if _, err := DecodeWAV(bytes.NewReader(truncated), 100); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated fmt chunk gave %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	if _, err := DecodeWAV(bytes.NewReader([]byte("RIFF\x04\x00\x00\x00AVI ")), 100); !errors.Is(err, ErrNotWAV) {
+		t.Errorf("non-WAVE RIFF gave %v, want %v", err, ErrNotWAV)
+	}
+}
+
+func TestExportWAVSetsSourceRate(t *testing.T) {
+	const rate = 100
+	path := filepath.Join(t.TempDir(), "sine.wav")
+
+	// the sine is built at a different rate, ExportWAV has to switch it
+	src := NewSine(5, 0.5, 10)
+	if err := ExportWAV(path, NewXY(src, src.Clone()), rate, 32, 0.5); err != nil {
+		t.Fatalf("ExportWAV: %v", err)
+	}
+
+	wav, err := LoadWAV(path, rate)
+	if err != nil {
+		t.Fatalf("LoadWAV: %v", err)
+	}
+	if len(wav.frames) != rate/2 {
+		t.Fatalf("exported %d frames, want %d", len(wav.frames), rate/2)
+	}
+	for i, xy := range wav.frames {
+		want := 0.5 * math.Sin(2*math.Pi*5*float64(i)/rate)
+		if math.Abs(xy.X-want) > 1e-6 || math.Abs(xy.Y-want) > 1e-6 {
+			t.Fatalf("frame %d = %+v, want %v on both channels", i, xy, want)
+		}
+	}
+}